@@ -0,0 +1,131 @@
+package mvcommon
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Group is a set of names sharing a detected prefix. A Group with an empty Prefix holds the leftover names that could
+// not be matched with any other name.
+type Group struct {
+	Prefix string
+	Names  []string
+}
+
+// DefaultClusterMinLength is the shortest prefix, in characters, a group shares when ClusterOptions.MinPrefixLength is not
+// set. Any two names share a letter or two, so without it unrelated names would be grouped by one.
+const DefaultClusterMinLength = 3
+
+// ClusterOptions configures ClusterByPrefix. The embedded Options configure the Detector each candidate group is
+// checked with.
+type ClusterOptions struct {
+	Options
+	// MinGroupSize is the smallest number of names a group may contain, values below 2 are treated as 2.
+	MinGroupSize int
+	// MinPrefixLength is the shortest prefix a group may share, in characters, defaults to DefaultClusterMinLength. The
+	// longer of it and Options.MinLength applies.
+	MinPrefixLength int
+	// MatchOn selects the part of each name prefixes are detected on, defaults to MatchBase.
+	MatchOn MatchOn
+}
+
+// ClusterByPrefix partitions names into groups that each share their own common prefix. Every match shared by at
+// least MinGroupSize names is found in a single search over all of them. The most specific matches, the longest, are
+// taken first, and each gathers the names no earlier one took. Each group is then named by detecting the prefix of its
+// names. Names that fit no group are returned in a final leftover Group with an empty Prefix. Groups are in the order
+// of their first name.
+func ClusterByPrefix(names []string, opts ClusterOptions) []Group {
+	minGroupSize := max(opts.MinGroupSize, 2)
+	minLength := opts.MinPrefixLength
+	if minLength <= 0 {
+		minLength = DefaultClusterMinLength
+	}
+	// Every name of a group shares its prefix, so MinShare does not apply.
+	detector := NewDetector(WithOptions(opts.Options), WithMinLength(max(opts.Options.MinLength, minLength)), WithMinShare(1))
+	keys := MatchKeys(names, opts.MatchOn)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	s := detector.search(keys)
+	s.required = minGroupSize
+	type ranked struct {
+		candidate
+		keyLen int
+	}
+	var candidates []ranked
+	s.walk(func(c candidate) {
+		seg := s.segments[c.sampleDoc]
+		candidates = append(candidates, ranked{c, seg.keyBounds[c.samplePos+c.length] - seg.keyBounds[c.samplePos]})
+	})
+	slices.SortFunc(candidates, func(a, b ranked) int {
+		return cmp.Or(
+			cmp.Compare(b.keyLen, a.keyLen),
+			cmp.Compare(b.names, a.names),
+			cmp.Compare(s.key(a.candidate), s.key(b.candidate)),
+		)
+	})
+
+	assigned := make([]bool, len(names))
+	left := len(names)
+	// members holds the names of each group, found by the candidate of the same index in found.
+	var members [][]int
+	var found []candidate
+	for _, c := range candidates {
+		if left < minGroupSize {
+			break
+		}
+		s.scan(c.from, c.to, c.depth)
+		var group []int
+		for _, sd := range s.seeds[c.from:c.to] {
+			if s.seen[sd.doc] != s.visit || assigned[sd.doc] {
+				continue
+			}
+			s.seen[sd.doc] = 0
+			group = append(group, sd.doc)
+		}
+		if len(group) < minGroupSize {
+			continue
+		}
+		slices.Sort(group)
+		for _, ni := range group {
+			assigned[ni] = true
+		}
+		left -= len(group)
+		members = append(members, group)
+		found = append(found, c.candidate)
+	}
+	order := make([]int, len(members))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int { return cmp.Compare(members[a][0], members[b][0]) })
+
+	var groups []Group
+	for _, gi := range order {
+		group, c := members[gi], found[gi]
+		// The match the group was found by names it should detection come up with nothing.
+		seg := s.segments[c.sampleDoc]
+		g := Group{Prefix: seg.name[seg.bounds[c.samplePos]:seg.bounds[c.samplePos+c.length]], Names: make([]string, len(group))}
+		memberKeys := make([]string, len(group))
+		for i, ni := range group {
+			g.Names[i] = names[ni]
+			memberKeys[i] = keys[ni]
+		}
+		if result, err := detector.Detect(memberKeys); err == nil {
+			g.Prefix = result.Prefix
+		}
+		groups = append(groups, g)
+	}
+
+	var leftover []string
+	for ni, name := range names {
+		if !assigned[ni] {
+			leftover = append(leftover, name)
+		}
+	}
+	if len(leftover) > 0 {
+		groups = append(groups, Group{Names: leftover})
+	}
+	return groups
+}
@@ -0,0 +1,147 @@
+package mvcommon
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestClusterByPrefix(t *testing.T) {
+	opts := ClusterOptions{
+		Options: Options{
+			StopWords: []string{" - ", "] ", "["},
+			Trim:      "_- ",
+			MinLength: 3,
+		},
+	}
+	tests := []struct {
+		name     string
+		names    []string
+		opts     ClusterOptions
+		expected []Group
+	}{
+		{
+			name:     "Cluster_Empty",
+			names:    []string{},
+			opts:     opts,
+			expected: nil,
+		},
+		{
+			name:  "Cluster_SingleGroup",
+			names: []string{"Report 234 - Draft1.txt", "Report 234 - Draft2.txt", "Report 234 - Final.txt"},
+			opts:  opts,
+			expected: []Group{
+				{Prefix: "Report 234", Names: []string{"Report 234 - Draft1.txt", "Report 234 - Draft2.txt", "Report 234 - Final.txt"}},
+			},
+		},
+		{
+			name: "Cluster_MixedSeriesWithLeftover",
+			names: []string{
+				"Show A - 01.mkv", "apple_pie.txt", "Show A - 02.mkv", "notes.md",
+				"apple_crumble.txt", "Show B - 01.mkv", "Show B - 02.mkv",
+			},
+			opts: opts,
+			expected: []Group{
+				{Prefix: "Show A", Names: []string{"Show A - 01.mkv", "Show A - 02.mkv"}},
+				{Prefix: "apple", Names: []string{"apple_pie.txt", "apple_crumble.txt"}},
+				{Prefix: "Show B", Names: []string{"Show B - 01.mkv", "Show B - 02.mkv"}},
+				{Names: []string{"notes.md"}},
+			},
+		},
+		{
+			name:  "Cluster_MatchesOnBaseNames",
+			names: []string{"/srv/a/Show - 01.mkv", "downloads/Show - 02.mkv"},
+			opts:  opts,
+			expected: []Group{
+				{Prefix: "Show", Names: []string{"/srv/a/Show - 01.mkv", "downloads/Show - 02.mkv"}},
+			},
+		},
+		{
+			name:  "Cluster_IgnoreCase",
+			names: []string{"REPORT_2024 scan.pdf", "notes.md", "Report_2024 notes.pdf", "Report_2024 final.pdf"},
+			opts: ClusterOptions{
+				Options: Options{
+					StopWords: opts.StopWords,
+					Trim:      opts.Trim,
+					MinLength: opts.MinLength,
+					FoldCase:  true,
+				},
+			},
+			expected: []Group{
+				{Prefix: "Report_2024", Names: []string{"REPORT_2024 scan.pdf", "Report_2024 notes.pdf", "Report_2024 final.pdf"}},
+				{Names: []string{"notes.md"}},
+			},
+		},
+		{
+			name:  "Cluster_MinGroupSize",
+			names: []string{"file_one.txt", "file_two.txt", "data_one.csv", "data_two.csv", "data_three.csv"},
+			opts: ClusterOptions{
+				Options:      opts.Options,
+				MinGroupSize: 3,
+			},
+			expected: []Group{
+				{Prefix: "data", Names: []string{"data_one.csv", "data_two.csv", "data_three.csv"}},
+				{Names: []string{"file_one.txt", "file_two.txt"}},
+			},
+		},
+		{
+			name:  "Cluster_NoMinMatch",
+			names: []string{"notes.md", "readme.txt", "Show A - 01.mkv", "Show A - 02.mkv"},
+			opts: ClusterOptions{
+				Options: Options{Trim: opts.Trim},
+			},
+			expected: []Group{
+				{Prefix: "Show A - 0", Names: []string{"Show A - 01.mkv", "Show A - 02.mkv"}},
+				{Names: []string{"notes.md", "readme.txt"}},
+			},
+		},
+		{
+			name:  "Cluster_MinPrefixLength",
+			names: []string{"ab1.txt", "ab2.txt", "notes.md"},
+			opts: ClusterOptions{
+				Options:         Options{Trim: opts.Trim},
+				MinPrefixLength: 2,
+			},
+			expected: []Group{
+				{Prefix: "ab", Names: []string{"ab1.txt", "ab2.txt"}},
+				{Names: []string{"notes.md"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := ClusterByPrefix(test.names, test.opts)
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("ClusterByPrefix(%v) got %#v; want %#v", test.names, result, test.expected)
+			}
+		})
+	}
+}
+
+// clusterBenchmarkNames returns n names from series of five episodes, each series spread over the names as a scan
+// of a downloads directory finds them.
+func clusterBenchmarkNames(n int) []string {
+	names := make([]string, n)
+	series := max(n/5, 1)
+	for i := range names {
+		names[i] = fmt.Sprintf("Series %s %05d - Episode %02d.mkv", []string{"Alpha", "Beta", "Gamma"}[i%series%3], i%series, i/series+1)
+	}
+	return names
+}
+
+func BenchmarkClusterByPrefix(b *testing.B) {
+	opts := ClusterOptions{Options: DefaultOptions()}
+	for _, n := range []int{10, 1000, 50000} {
+		names := clusterBenchmarkNames(n)
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if groups := ClusterByPrefix(names, opts); len(groups) != n/5 {
+					b.Fatalf("ClusterByPrefix() got %d groups; want %d", len(groups), n/5)
+				}
+			}
+		})
+	}
+}
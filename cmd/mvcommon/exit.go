@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/arran4/mvcommon"
+)
+
+// Exit codes returned by the mvcommon command.
+const (
+	exitFailure        = 1
+	exitUsage          = 2
+	exitNoCommonPrefix = 3
+	exitNoSelection    = 4
+	exitConflict       = 5
+)
+
+// exitCode maps an error returned by a command to the process exit code.
+func exitCode(err error) int {
+	var userErr *UserError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, mvcommon.ErrTooFewFiles), errors.As(err, &userErr):
+		return exitUsage
+	case errors.Is(err, mvcommon.ErrNoCommonPrefix):
+		return exitNoCommonPrefix
+	case errors.Is(err, mvcommon.ErrNoSelection):
+		return exitNoSelection
+	case errors.Is(err, mvcommon.ErrDestinationExists):
+		return exitConflict
+	default:
+		return exitFailure
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/arran4/mvcommon"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "Nil", err: nil, expected: 0},
+		{name: "Other", err: errors.New("boom"), expected: exitFailure},
+		{name: "UserError", err: NewUserError(errors.New("bad flag"), "flag parse error"), expected: exitUsage},
+		{name: "TooFewFiles", err: fmt.Errorf("mvcommon failed: %w", mvcommon.ErrTooFewFiles), expected: exitUsage},
+		{name: "NoCommonPrefix", err: fmt.Errorf("mvcommon failed: %w", mvcommon.ErrNoCommonPrefix), expected: exitNoCommonPrefix},
+		{name: "NoSelection", err: fmt.Errorf("mvcommon failed: %w", mvcommon.ErrNoSelection), expected: exitNoSelection},
+		{name: "Conflict", err: fmt.Errorf("mvcommon failed: %w", mvcommon.ErrDestinationExists), expected: exitConflict},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := exitCode(test.err); got != test.expected {
+				t.Errorf("exitCode(%v) got %d; want %d", test.err, got, test.expected)
+			}
+		})
+	}
+}
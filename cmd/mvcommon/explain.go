@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"github.com/arran4/mvcommon"
+	"github.com/rivo/uniseg"
+	"io"
+	"os"
+	"strings"
+)
+
+// Explain is a subcommand `mvcommon explain`
+//
+// Flags:
+//
+//	stopWords:	--stopword		Stop word to stop common prefix detection
+//	trim:		--trim			Characters to trim
+//	minMatch:	--min			Minimum size of common segment
+//	normalize:	--normalize		Unicode normalization to compare names in: none, nfc, nfd or nfkc (default: none)
+//	ignoreCase:	--ignore-case	Compare names case-insensitively
+//	tokens:		--tokens		Match whole words instead of characters and pick the longest shared run of words
+//	anchor:		--anchor		Where the shared part of the names may be: prefix, suffix (before the extension) or any (default: any)
+//	keepExt:	--keep-ext		Match on file extensions too instead of leaving them out
+//	minShare:	--min-share		Fraction of the files the common part must be found in, the others are left in place (default: 1)
+//	strategy:	--strategy		How to pick between shared parts: auto, earliest, longest, tokens or weighted (default: auto)
+//	matchOn:	--match-on		Part of each file detection runs on: path, base or stem (default: base)
+func Explain(stopWords string, trim string, minMatch int, normalize string, ignoreCase bool, tokens bool, anchor string, keepExt bool, minShare float64, strategy string, matchOn string, files ...string) error {
+	if len(files) < 2 {
+		return mvcommon.ErrTooFewFiles
+	}
+
+	matchOnMode, err := mvcommon.ParseMatchOn(matchOn)
+	if err != nil {
+		return err
+	}
+
+	detector, err := detectFlags{
+		stopWords:  stopWords,
+		trim:       trim,
+		minMatch:   minMatch,
+		normalize:  normalize,
+		ignoreCase: ignoreCase,
+		tokens:     tokens,
+		anchor:     anchor,
+		keepExt:    keepExt,
+		minShare:   minShare,
+		strategy:   strategy,
+	}.detector()
+	if err != nil {
+		return err
+	}
+	result, err := detector.Detect(mvcommon.MatchKeys(files, matchOnMode))
+	if err != nil {
+		return err
+	}
+
+	writeExplanation(os.Stdout, result)
+	return nil
+}
+
+// writeExplanation prints result with each match underlined in its name.
+func writeExplanation(w io.Writer, result mvcommon.Result) {
+	fmt.Fprintf(w, "Prefix:     %q\n", result.Prefix)
+	fmt.Fprintf(w, "Untrimmed:  %q\n", result.Untrimmed)
+	fmt.Fprintf(w, "Trimmed:    %q\n", result.TrimChars)
+	fmt.Fprintf(w, "Stop words: %s\n", quoteList(result.StopWords))
+	fmt.Fprintf(w, "Score:      %.3f\n", result.Score)
+	fmt.Fprintln(w, "Matches:")
+	for _, m := range result.Matches {
+		fmt.Fprintf(w, "  %s\n", m.Name)
+		indent := uniseg.StringWidth(m.Name[:m.Offset])
+		width := uniseg.StringWidth(m.Name[m.Offset : m.Offset+m.Length])
+		fmt.Fprintf(w, "  %s%s offset %d, length %d\n", strings.Repeat(" ", indent), strings.Repeat("^", width), m.Offset, m.Length)
+	}
+	if len(result.Unmatched) > 0 {
+		fmt.Fprintln(w, "Unmatched:")
+		for _, name := range result.Unmatched {
+			fmt.Fprintf(w, "  %s\n", name)
+		}
+	}
+}
+
+func quoteList(list []string) string {
+	if len(list) == 0 {
+		return "none"
+	}
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,92 @@
+// Generated by github.com/arran4/go-subcommand/cmd/gosubc
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+type ExplainCmd struct {
+	*flag.FlagSet
+	parent        *RootCmd
+	stopWords     string
+	trim          string
+	minMatch      int
+	normalize     string
+	ignoreCase    bool
+	tokens        bool
+	anchor        string
+	keepExt       bool
+	minShare      float64
+	strategy      string
+	matchOn       string
+	files         []string
+	CommandAction func(c *ExplainCmd) error
+}
+
+func (c *ExplainCmd) Usage() {
+	fmt.Fprintf(os.Stderr, "Usage of %s explain:\n", os.Args[0])
+	c.FlagSet.PrintDefaults()
+}
+
+func (c *RootCmd) NewExplain() *ExplainCmd {
+	set := flag.NewFlagSet("explain", flag.ExitOnError)
+	v := &ExplainCmd{
+		FlagSet: set,
+		parent:  c,
+	}
+	set.Usage = v.Usage
+
+	set.StringVar(&v.stopWords, "stopword", "", "Stop word to stop common prefix detection")
+
+	set.StringVar(&v.trim, "trim", "", "Characters to trim")
+
+	set.IntVar(&v.minMatch, "min", 0, "Minimum size of common segment")
+
+	set.StringVar(&v.normalize, "normalize", "none", "Unicode normalization to compare names in: none, nfc, nfd or nfkc")
+
+	set.BoolVar(&v.ignoreCase, "ignore-case", false, "Compare names case-insensitively")
+
+	set.BoolVar(&v.tokens, "tokens", false, "Match whole words instead of characters and pick the longest shared run of words")
+
+	set.StringVar(&v.anchor, "anchor", "any", "Where the shared part of the names may be: prefix, suffix (before the extension) or any")
+
+	set.BoolVar(&v.keepExt, "keep-ext", false, "Match on file extensions too instead of leaving them out")
+
+	set.Float64Var(&v.minShare, "min-share", 1, "Fraction of the files the common part must be found in, the others are left in place")
+
+	set.StringVar(&v.strategy, "strategy", "auto", "How to pick between shared parts: auto, earliest, longest, tokens or weighted")
+
+	set.StringVar(&v.matchOn, "match-on", "base", "Part of each file detection runs on: path, base or stem")
+
+	v.CommandAction = func(c *ExplainCmd) error {
+
+		return Explain(c.stopWords, c.trim, c.minMatch, c.normalize, c.ignoreCase, c.tokens, c.anchor, c.keepExt, c.minShare, c.strategy, c.matchOn, c.files...)
+	}
+	return v
+}
+
+func (c *ExplainCmd) Execute(args []string) error {
+	if err := c.FlagSet.Parse(args); err != nil {
+		return NewUserError(err, fmt.Sprintf("flag parse error %s", err.Error()))
+	}
+	remainingArgs := c.FlagSet.Args()
+	// Handle vararg files
+	{
+		varArgStart := 0
+		if varArgStart > len(remainingArgs) {
+			varArgStart = len(remainingArgs)
+		}
+		varArgs := remainingArgs[varArgStart:]
+		c.files = varArgs
+	}
+
+	if c.CommandAction != nil {
+		if err := c.CommandAction(c); err != nil {
+			return fmt.Errorf("explain failed: %w", err)
+		}
+	}
+	return nil
+}
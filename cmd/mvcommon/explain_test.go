@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arran4/mvcommon"
+)
+
+func TestWriteExplanation(t *testing.T) {
+	result, err := mvcommon.NewDetector(mvcommon.WithTrim("_- "), mvcommon.WithMinLength(3)).Detect([]string{"[Draft] Report 234 - v1.txt", "[Final] Report 234 - v2.txt"})
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	writeExplanation(&buf, result)
+	expected := `Prefix:     "Report 234"
+Untrimmed:  "Report 234 - "
+Trimmed:    " -"
+Stop words: " - ", "] "
+Score:      0.111
+Matches:
+  [Draft] Report 234 - v1.txt
+          ^^^^^^^^^^ offset 8, length 10
+  [Final] Report 234 - v2.txt
+          ^^^^^^^^^^ offset 8, length 10
+`
+	if buf.String() != expected {
+		t.Errorf("writeExplanation() got:\n%s\nwant:\n%s", buf.String(), expected)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/arran4/mvcommon"
+)
+
+const (
+	outputText   = "text"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+)
+
+// groupReport is a detected group in machine-readable output.
+type groupReport struct {
+	Prefix string   `json:"prefix"`
+	Files  []string `json:"files"`
+}
+
+// runReport is the document written by --output json.
+type runReport struct {
+	DryRun     bool                 `json:"dry_run"`
+	RunID      string               `json:"run_id,omitempty"`
+	Groups     []groupReport        `json:"groups"`
+	Unmatched  []string             `json:"unmatched"`
+	Operations []mvcommon.Operation `json:"operations"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// The events written by --output ndjson, one per line, told apart by their "event" field.
+type (
+	groupEvent struct {
+		Event string `json:"event"`
+		groupReport
+	}
+	unmatchedEvent struct {
+		Event string `json:"event"`
+		File  string `json:"file"`
+	}
+	operationEvent struct {
+		Event string `json:"event"`
+		mvcommon.Operation
+	}
+	finishEvent struct {
+		Event  string `json:"event"`
+		DryRun bool   `json:"dry_run"`
+		RunID  string `json:"run_id,omitempty"`
+		Error  string `json:"error,omitempty"`
+	}
+)
+
+// reporter writes the outcome of a run in the format selected by --output. Human readable text goes to stdout in text
+// mode and to stderr otherwise, so that stdout only carries JSON. Answers to interactive prompts are read from in.
+type reporter struct {
+	format string
+	human  io.Writer
+	out    io.Writer
+	in     *bufio.Reader
+	report runReport
+}
+
+func newReporter(format string, dryRun bool) (*reporter, error) {
+	r := &reporter{
+		format: format,
+		human:  os.Stdout,
+		out:    os.Stdout,
+		in:     bufio.NewReader(os.Stdin),
+		report: runReport{
+			DryRun:     dryRun,
+			Groups:     []groupReport{},
+			Unmatched:  []string{},
+			Operations: []mvcommon.Operation{},
+		},
+	}
+	switch format {
+	case outputText:
+	case outputJSON, outputNDJSON:
+		r.human = os.Stderr
+	default:
+		return r, fmt.Errorf("invalid output format %q, expected %s, %s or %s", format, outputText, outputJSON, outputNDJSON)
+	}
+	return r, nil
+}
+
+func (r *reporter) printf(format string, a ...any) {
+	fmt.Fprintf(r.human, format, a...)
+}
+
+func (r *reporter) println(a ...any) {
+	fmt.Fprintln(r.human, a...)
+}
+
+func (r *reporter) emit(e any) {
+	if r.format == outputNDJSON {
+		json.NewEncoder(r.out).Encode(e)
+	}
+}
+
+// group records a group of files about to be moved.
+func (r *reporter) group(group mvcommon.Group) {
+	g := groupReport{Prefix: group.Prefix, Files: group.Names}
+	r.report.Groups = append(r.report.Groups, g)
+	r.emit(groupEvent{Event: "group", groupReport: g})
+}
+
+// unmatched records a file left in place because it shares no prefix.
+func (r *reporter) unmatched(file string) {
+	r.report.Unmatched = append(r.report.Unmatched, file)
+	r.emit(unmatchedEvent{Event: "unmatched", File: file})
+}
+
+// operation records an operation as it is performed.
+func (r *reporter) operation(op mvcommon.Operation) {
+	r.emit(operationEvent{Event: "operation", Operation: op})
+}
+
+// planned records the operations of a plan that is not going to be applied.
+func (r *reporter) planned(plan *mvcommon.Plan) {
+	for _, op := range plan.Operations {
+		r.operation(op)
+	}
+}
+
+// finish writes the final JSON document or event. plan may be nil if the run failed before planning.
+func (r *reporter) finish(plan *mvcommon.Plan, runID string, err error) {
+	if plan != nil {
+		r.report.Operations = plan.Operations
+	}
+	r.report.RunID = runID
+	if err != nil {
+		r.report.Error = err.Error()
+	}
+
+	switch r.format {
+	case outputJSON:
+		enc := json.NewEncoder(r.out)
+		enc.SetIndent("", "  ")
+		enc.Encode(r.report)
+	case outputNDJSON:
+		e := finishEvent{Event: "done", DryRun: r.report.DryRun, RunID: runID}
+		if err != nil {
+			e.Event = "error"
+			e.Error = err.Error()
+		}
+		r.emit(e)
+	}
+}
+
+// fail finishes a run that failed before its plan was applied and returns err.
+func (r *reporter) fail(err error) error {
+	r.finish(nil, "", err)
+	return err
+}
@@ -51,17 +51,42 @@ func executeUsage(out io.Writer, templateName string, data interface{}) error {
 
 type RootCmd struct {
 	*flag.FlagSet
-	Commands      map[string]Cmd
-	Version       string
-	Commit        string
-	Date          string
-	stopWords     string
-	trim          string
-	minMatch      int
-	dryRun        bool
-	interactive   bool
-	files         []string
-	CommandAction func(c *RootCmd) error
+	Commands       map[string]Cmd
+	Version        string
+	Commit         string
+	Date           string
+	stopWords      string
+	trim           string
+	minMatch       int
+	normalize      string
+	ignoreCase     bool
+	tokens         bool
+	anchor         string
+	keepExt        bool
+	minShare       float64
+	strategy       string
+	pattern        string
+	dryRun         bool
+	interactive    bool
+	tui            bool
+	cluster        bool
+	scan           string
+	recursive      bool
+	maxDepth       int
+	include        string
+	exclude        string
+	hidden         bool
+	onConflict     string
+	matchOn        string
+	parents        string
+	dest           string
+	folderTemplate string
+	groupByExt     bool
+	verify         string
+	xattrs         bool
+	output         string
+	files          []string
+	CommandAction  func(c *RootCmd) error
 }
 
 func (c *RootCmd) Usage() {
@@ -95,16 +120,69 @@ func NewRoot(name, version, commit, date string) (*RootCmd, error) {
 
 	c.IntVar(&c.minMatch, "min", 0, "Minimum size of common segment")
 
+	c.StringVar(&c.normalize, "normalize", "none", "Unicode normalization to compare names in: none, nfc, nfd or nfkc")
+
+	c.BoolVar(&c.ignoreCase, "ignore-case", false, "Compare names case-insensitively")
+
+	c.BoolVar(&c.tokens, "tokens", false, "Match whole words instead of characters and pick the longest shared run of words")
+
+	c.StringVar(&c.anchor, "anchor", "any", "Where the shared part of the names may be: prefix, suffix (before the extension) or any")
+
+	c.BoolVar(&c.keepExt, "keep-ext", false, "Match on file extensions too instead of leaving them out")
+
+	c.Float64Var(&c.minShare, "min-share", 1, "Fraction of the files the common part must be found in, the others are left in place")
+
+	c.StringVar(&c.strategy, "strategy", "auto", "How to pick between shared parts: auto, earliest, longest, tokens or weighted")
+
+	c.StringVar(&c.pattern, "pattern", "", "Regular expression whose first named capture group, or whole match, is each file's folder name instead of a detected prefix")
+
 	c.BoolVar(&c.dryRun, "dry-run", false, "Perform a dry run without moving files")
 
 	c.BoolVar(&c.interactive, "interactive", false, "Enable interactive mode for file selection")
 
+	c.BoolVar(&c.tui, "tui", false, "Review and adjust the folders on a full-screen terminal before moving files")
+
+	c.BoolVar(&c.cluster, "cluster", false, "Sort files into a folder per detected prefix group")
+
+	c.StringVar(&c.scan, "scan", "", "Directory to collect files from, implies --cluster")
+
+	c.BoolVar(&c.recursive, "recursive", false, "Scan sub directories")
+
+	c.IntVar(&c.maxDepth, "max-depth", 0, "Maximum depth of a recursive scan, 0 for no limit")
+
+	c.StringVar(&c.include, "include", "", "Comma separated globs of file names to scan")
+
+	c.StringVar(&c.exclude, "exclude", "", "Comma separated globs of file and directory names to skip while scanning")
+
+	c.BoolVar(&c.hidden, "hidden", false, "Scan hidden files and directories")
+
+	c.StringVar(&c.onConflict, "on-conflict", "fail", "What to do when a destination exists: skip, overwrite, rename, keep-newer, keep-larger or fail")
+
+	c.StringVar(&c.matchOn, "match-on", "base", "Part of each file detection runs on: path, base or stem")
+
+	c.StringVar(&c.parents, "parents", "common", "Where folders go when files are in several directories: common, per-parent or refuse")
+
+	c.StringVar(&c.dest, "dest", "", "Folder to create the detected folders in, defaults to the directory containing the files")
+
+	c.StringVar(&c.folderTemplate, "folder-template", "{{.Prefix}}", "Template for the folder name such as {{.Prefix | lower}} or {{.Year}}/{{.Prefix}}")
+
+	c.BoolVar(&c.groupByExt, "group-by-ext", false, "Move files into a sub folder per extension inside each detected folder")
+
+	c.StringVar(&c.verify, "verify", "size", "How to check files copied across file systems: size or checksum")
+
+	c.BoolVar(&c.xattrs, "xattrs", false, "Preserve extended attributes of files copied across file systems")
+
+	c.StringVar(&c.output, "output", "text", "Output format: text, json or ndjson, human readable text goes to stderr for json and ndjson")
+
 	c.CommandAction = func(c *RootCmd) error {
 
-		Run(c.stopWords, c.trim, c.minMatch, c.dryRun, c.interactive, c.files...)
-		return nil
+		return Run(c.stopWords, c.trim, c.minMatch, c.normalize, c.ignoreCase, c.tokens, c.anchor, c.keepExt, c.minShare, c.strategy, c.pattern, c.dryRun, c.interactive, c.tui, c.cluster, c.scan, c.recursive, c.maxDepth, c.include, c.exclude, c.hidden, c.onConflict, c.matchOn, c.parents, c.dest, c.folderTemplate, c.groupByExt, c.verify, c.xattrs, c.output, c.files...)
 	}
 
+	c.Commands["undo"] = c.NewUndo()
+
+	c.Commands["explain"] = c.NewExplain()
+
 	c.Commands["help"] = &InternalCommand{
 		Exec: func(args []string) error {
 			for _, arg := range args {
@@ -1,10 +1,14 @@
 package main
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"github.com/arran4/mvcommon"
+	"io"
 	"os"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -15,103 +19,478 @@ import (
 //	stopWords:	--stopword		Stop word to stop common prefix detection
 //	trim:		--trim			Characters to trim
 //	minMatch:	--min			Minimum size of common segment
+//	normalize:	--normalize		Unicode normalization to compare names in: none, nfc, nfd or nfkc (default: none)
+//	ignoreCase:	--ignore-case	Compare names case-insensitively
+//	tokens:		--tokens		Match whole words instead of characters and pick the longest shared run of words
+//	anchor:		--anchor		Where the shared part of the names may be: prefix, suffix (before the extension) or any (default: any)
+//	keepExt:	--keep-ext		Match on file extensions too instead of leaving them out
+//	minShare:	--min-share		Fraction of the files the common part must be found in, the others are left in place (default: 1)
+//	strategy:	--strategy		How to pick between shared parts: auto, earliest, longest, tokens or weighted (default: auto)
+//	pattern:	--pattern		Regular expression whose first named capture group, or whole match, is each file's folder name instead of a detected prefix
 //	dryRun:		--dry-run		Perform a dry run without moving files
 //	interactive:	--interactive	Enable interactive mode for file selection
+//	tui:		--tui			Review and adjust the folders on a full-screen terminal before moving files
+//	cluster:	--cluster		Sort files into a folder per detected prefix group
+//	scan:		--scan			Directory to collect files from, implies --cluster
+//	recursive:	--recursive		Scan sub directories
+//	maxDepth:	--max-depth		Maximum depth of a recursive scan, 0 for no limit
+//	include:	--include		Comma separated globs of file names to scan
+//	exclude:	--exclude		Comma separated globs of file and directory names to skip while scanning
+//	hidden:		--hidden		Scan hidden files and directories
+//	onConflict:	--on-conflict	What to do when a destination exists: skip, overwrite, rename, keep-newer, keep-larger or fail (default: fail)
+//	matchOn:	--match-on		Part of each file detection runs on: path, base or stem (default: base)
+//	parents:	--parents		Where folders go when files are in several directories: common, per-parent or refuse (default: common)
+//	dest:		--dest			Folder to create the detected folders in, defaults to the directory containing the files
+//	folderTemplate:	--folder-template	Template for the folder name such as {{.Prefix | lower}} or {{.Year}}/{{.Prefix}} (default: {{.Prefix}})
+//	groupByExt:	--group-by-ext	Move files into a sub folder per extension inside each detected folder
+//	verify:		--verify		How to check files copied across file systems: size or checksum (default: size)
+//	xattrs:		--xattrs		Preserve extended attributes of files copied across file systems
+//	output:		--output		Output format: text, json or ndjson, human readable text goes to stderr for json and ndjson (default: text)
 //	files:		...				Files to move
-func Run(stopWords string, trim string, minMatch int, dryRun bool, interactive bool, files ...string) {
+func Run(stopWords string, trim string, minMatch int, normalize string, ignoreCase bool, tokens bool, anchor string, keepExt bool, minShare float64, strategy string, pattern string, dryRun bool, interactive bool, tui bool, cluster bool, scan string, recursive bool, maxDepth int, include string, exclude string, hidden bool, onConflict string, matchOn string, parents string, dest string, folderTemplate string, groupByExt bool, verify string, xattrs bool, output string, files ...string) error {
+	r, err := newReporter(output, dryRun)
+	if err != nil {
+		return r.fail(err)
+	}
+
+	if scan != "" {
+		scanned, err := mvcommon.Scan(scan, mvcommon.ScanOptions{
+			Recursive: recursive,
+			MaxDepth:  maxDepth,
+			Include:   splitList(include),
+			Exclude:   splitList(exclude),
+			Hidden:    hidden,
+		})
+		if err != nil {
+			return r.fail(err)
+		}
+		files = append(files, scanned...)
+		cluster = true
+	}
+
 	if len(files) < 2 {
-		fmt.Println("Error: At least two files required")
-		Usage()
-		os.Exit(1)
+		Usage(r.human)
+		return r.fail(mvcommon.ErrTooFewFiles)
 	}
 
-	var stopWordsSlice []string
-	if stopWords != "" {
-		stopWordsSlice = strings.Split(stopWords, ",")
-	} else {
-		stopWordsSlice = mvcommon.DefaultStopWords
+	policy, err := mvcommon.ParseConflictPolicy(onConflict)
+	if err != nil {
+		return r.fail(err)
+	}
+
+	verifyMode, err := mvcommon.ParseVerifyMode(verify)
+	if err != nil {
+		return r.fail(err)
+	}
+
+	matchOnMode, err := mvcommon.ParseMatchOn(matchOn)
+	if err != nil {
+		return r.fail(err)
+	}
+
+	parentPolicy, err := mvcommon.ParseParentPolicy(parents)
+	if err != nil {
+		return r.fail(err)
+	}
+
+	tmpl, err := mvcommon.ParseFolderTemplate(folderTemplate)
+	if err != nil {
+		return r.fail(err)
 	}
 
+	detector, err := detectFlags{
+		stopWords:  stopWords,
+		trim:       trim,
+		minMatch:   minMatch,
+		normalize:  normalize,
+		ignoreCase: ignoreCase,
+		tokens:     tokens,
+		anchor:     anchor,
+		keepExt:    keepExt,
+		minShare:   minShare,
+		strategy:   strategy,
+	}.detector()
+	if err != nil {
+		return r.fail(err)
+	}
+
+	planOpts := mvcommon.PlanOptions{
+		Dest:       dest,
+		Template:   tmpl,
+		Parents:    parentPolicy,
+		GroupByExt: groupByExt,
+	}
+	if interactive && tui {
+		return r.fail(NewUserError(nil, "--interactive cannot be combined with --tui"))
+	}
+	var session *interactiveSession
+	if interactive || tui {
+		session = &interactiveSession{plan: planOpts, policy: policy, dryRun: dryRun}
+	}
+
+	var groups []mvcommon.Group
+	switch {
+	case tui:
+		groups, err = reviewInTUI(r, session, detector, pattern, cluster, matchOnMode, files)
+	case pattern != "":
+		groups, err = groupByPattern(r, pattern, matchOnMode, interactive, files)
+	case cluster:
+		groups, err = detectClusters(r, detector, matchOnMode, session, files)
+	default:
+		groups, err = detectSingleFolder(r, detector, matchOnMode, session, files)
+	}
+	if errors.Is(err, errQuit) {
+		r.println("Quit without making any changes.")
+		r.finish(nil, "", nil)
+		return nil
+	}
+	if err != nil {
+		return r.fail(err)
+	}
+	if session != nil {
+		planOpts, dryRun = session.plan, session.dryRun
+		r.report.DryRun = dryRun
+	}
+	for _, group := range groups {
+		r.group(group)
+	}
+
+	plan, err := planGroups(groups, planOpts, policy)
+	if err != nil {
+		return r.fail(err)
+	}
+
+	return executePlan(r, plan, dryRun, mvcommon.ApplyOptions{
+		Out:            r.human,
+		Verify:         verifyMode,
+		PreserveXattrs: xattrs,
+		OnOperation:    r.operation,
+	})
+}
+
+// planGroups plans moving each group to its folder and resolves conflicts with policy.
+func planGroups(groups []mvcommon.Group, opts mvcommon.PlanOptions, policy mvcommon.ConflictPolicy) (*mvcommon.Plan, error) {
+	plan, err := mvcommon.PlanGroups(groups, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := plan.ResolveConflicts(policy); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// detectSingleFolder detects the folder all files go to, asking the user when session is not nil.
+func detectSingleFolder(r *reporter, detector *mvcommon.Detector, matchOn mvcommon.MatchOn, session *interactiveSession, files []string) ([]mvcommon.Group, error) {
 	var folderName string
 
-	if interactive {
-		files, folderName = interactiveFileSelection(files, stopWordsSlice, trim, minMatch)
+	if session != nil {
+		var err error
+		files, folderName, err = interactiveFileSelection(r, session, files, detector, matchOn)
+		if err != nil {
+			return nil, err
+		}
 	} else {
-		folderName = mvcommon.CommonPrefixSplit(files, stopWordsSlice, trim, minMatch)
+		keys := mvcommon.MatchKeys(files, matchOn)
+		result, err := detector.Detect(keys)
+		if err != nil {
+			return nil, err
+		}
+		folderName = result.Prefix
+		files = leaveOutUnmatched(r, files, keys, result)
 	}
 	if folderName == "" {
-		fmt.Println("Error: No common prefix found! Exiting")
-		os.Exit(1)
+		return nil, mvcommon.ErrNoCommonPrefix
 	}
 
 	if len(files) == 0 {
-		fmt.Println("No files selected. Exiting.")
-		os.Exit(1)
+		return nil, mvcommon.ErrNoSelection
+	}
+
+	return []mvcommon.Group{{Prefix: folderName, Names: files}}, nil
+}
+
+func groupByPattern(r *reporter, pattern string, matchOn mvcommon.MatchOn, interactive bool, files []string) ([]mvcommon.Group, error) {
+	if interactive {
+		return nil, NewUserError(nil, "--interactive cannot be combined with --pattern")
+	}
+	re, err := mvcommon.ParsePattern(pattern)
+	if err != nil {
+		return nil, NewUserError(err, "invalid --pattern")
+	}
+
+	var selected []mvcommon.Group
+	for _, group := range mvcommon.GroupByPattern(files, re, matchOn) {
+		if group.Prefix == "" {
+			for _, file := range group.Names {
+				r.printf("Skipping %s: does not match pattern %q\n", file, pattern)
+				r.unmatched(file)
+			}
+			continue
+		}
+		selected = append(selected, group)
+	}
+
+	if len(selected) == 0 {
+		return nil, mvcommon.ErrNoCommonPrefix
+	}
+	return selected, nil
+}
+
+// detectClusters groups the files by prefix, asking the user about each group when session is not nil.
+func detectClusters(r *reporter, detector *mvcommon.Detector, matchOn mvcommon.MatchOn, session *interactiveSession, files []string) ([]mvcommon.Group, error) {
+	groups := mvcommon.ClusterByPrefix(files, mvcommon.ClusterOptions{
+		Options: detector.Options(),
+		MatchOn: matchOn,
+	})
+
+	var selected []mvcommon.Group
+	for _, group := range groups {
+		if group.Prefix == "" {
+			for _, file := range group.Names {
+				r.printf("Skipping %s: no common prefix with other files\n", file)
+				r.unmatched(file)
+			}
+			continue
+		}
+
+		if session != nil {
+			var err error
+			group.Names, group.Prefix, err = interactiveFileSelection(r, session, group.Names, detector, matchOn)
+			if err != nil {
+				return nil, err
+			}
+			if group.Prefix == "" || len(group.Names) == 0 {
+				continue
+			}
+		}
+		selected = append(selected, group)
+	}
+
+	if len(selected) == 0 {
+		return nil, mvcommon.ErrNoCommonPrefix
+	}
+	return selected, nil
+}
+
+// leaveOutUnmatched reports the files whose key the detected prefix was not found in and returns the others.
+func leaveOutUnmatched(r *reporter, files, keys []string, result mvcommon.Result) []string {
+	if len(result.Unmatched) == 0 {
+		return files
+	}
+	matched := make([]string, 0, len(files))
+	for i, file := range files {
+		if slices.Contains(result.Unmatched, keys[i]) {
+			r.printf("Skipping %s: does not share the prefix %q\n", file, result.Prefix)
+			r.unmatched(file)
+			continue
+		}
+		matched = append(matched, file)
+	}
+	return matched
+}
+
+func executePlan(r *reporter, plan *mvcommon.Plan, dryRun bool, opts mvcommon.ApplyOptions) error {
+	for _, op := range plan.Operations {
+		if op.Kind != mvcommon.OperationMkdir {
+			continue
+		}
+		if dryRun {
+			r.printf("[Dry Run] Creating folder: %s\n", op.Destination)
+		} else {
+			r.printf("Creating folder: %s\n", op.Destination)
+		}
 	}
 
 	if dryRun {
-		fmt.Printf("[Dry Run] Creating folder: %s\n", folderName)
+		if err := plan.WriteDryRun(r.human); err != nil {
+			return r.fail(err)
+		}
+		r.planned(plan)
+		r.finish(plan, "", nil)
+		r.println("Operation completed successfully.")
+		return nil
+	}
+
+	if path, err := mvcommon.DefaultJournalPath(); err != nil {
+		r.printf("Warning: not journaling this run, undo will be unavailable: %v\n", err)
 	} else {
-		fmt.Printf("Creating folder: %s\n", folderName)
+		opts.Journal = mvcommon.NewJournal(path)
 	}
 
-	// Move files into the folder
-	if err := mvcommon.MoveFilesToFolder(folderName, files, dryRun); err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+	var runID string
+	err := mvcommon.Apply(plan, opts)
+	if opts.Journal != nil {
+		runID = opts.Journal.RunID
+		r.printf("Run ID: %s (revert with: mvcommon undo -run %s)\n", runID, runID)
 	}
+	r.finish(plan, runID, err)
+	if err != nil {
+		return err
+	}
+
+	r.println("Operation completed successfully.")
+	return nil
+}
+
+// maxCandidates is the number of folder names interactive mode offers to choose from.
+const maxCandidates = 5
+
+// candidateChoice matches the command choosing one of the folder names found, other input starting with c is a
+// selection such as c*.txt.
+var candidateChoice = regexp.MustCompile(`^c(\d+)$`)
 
-	fmt.Println("Operation completed successfully.")
+// errQuit is returned when the user quits interactive mode or the TUI, the run ends without changing anything.
+var errQuit = errors.New("quit without changes")
+
+// interactiveSession holds the settings interactive mode and the TUI let the user change before the plan is made.
+type interactiveSession struct {
+	plan   mvcommon.PlanOptions
+	policy mvcommon.ConflictPolicy
+	dryRun bool
 }
 
-func interactiveFileSelection(files []string, stopWords []string, trim string, minMatch int) ([]string, string) {
-	reader := bufio.NewReader(os.Stdin)
+func interactiveFileSelection(r *reporter, session *interactiveSession, files []string, detector *mvcommon.Detector, matchOn mvcommon.MatchOn) ([]string, string, error) {
 	selectedFiles := files
+	// chosenName is a folder name the user picked or typed, kept until they reset.
+	var chosenName string
 	for {
 
 		// Print files with indices
-		fmt.Println()
-		fmt.Println("Interactive Mode Enabled:")
-		// Find common prefix
-		folderName := mvcommon.CommonPrefixSplit(selectedFiles, stopWords, trim, minMatch)
+		r.println()
+		r.println("Interactive Mode Enabled:")
+		// Find common prefixes
+		keys := mvcommon.MatchKeys(selectedFiles, matchOn)
+		results, err := detector.DetectCandidates(keys, maxCandidates)
+		if err != nil {
+			results = nil
+		}
+		var candidates []string
+		for _, result := range results {
+			candidates = append(candidates, result.Prefix)
+		}
+		folderName := chosenName
+		if folderName == "" && len(candidates) > 0 {
+			folderName = candidates[0]
+		}
+		// active is the detection the folder name comes from, the best one for a name of the user's own. The files
+		// its common part was not found in, with --min-share, are left out.
+		var active mvcommon.Result
+		if len(results) > 0 {
+			active = results[0]
+			if i := slices.Index(candidates, folderName); i >= 0 {
+				active = results[i]
+			}
+		}
 		if folderName == "" {
 			fmt.Fprintln(os.Stderr, "Error: No common prefix found!")
 		} else {
-			fmt.Printf("Will move the files to %q\n", folderName)
-			fmt.Println()
+			r.printf("Will move the files to %q\n", folderName)
+		}
+		if session.plan.Dest != "" {
+			r.printf("Inside: %s\n", session.plan.Dest)
+		}
+		if session.dryRun {
+			r.println("Dry run: no files will be changed")
+		}
+		r.println()
+
+		if len(candidates) > 1 {
+			r.println("Folder names found:")
+			for i, candidate := range candidates {
+				r.printf("c%d. %s\n", i+1, candidate)
+			}
+			r.println()
 		}
 
-		fmt.Println("For the following files:")
+		r.println("For the following files:")
 		for i, file := range selectedFiles {
-			fmt.Printf("%d. %s\n", i+1, file)
+			if slices.Contains(active.Unmatched, keys[i]) {
+				r.printf("%d. %s (left out, does not share %q)\n", i+1, file, active.Prefix)
+				continue
+			}
+			r.printf("%d. %s\n", i+1, file)
 		}
 
 		var nextSelectedFiles = make([]string, 0, len(selectedFiles))
 
 		// Prompt user for confirmation or range input
-		fmt.Println()
-		fmt.Println("Enter file numbers to include (e.g., 1,2,3 or 1-3,5-6) or press 'a' to confirm all, 'r' to reset:")
+		r.println()
+		r.println("Enter file numbers to include (e.g., 1,2,3 or 1-3,5-6) or press 'a' to confirm all, 'r' to reset:")
+		r.println("Files can also be picked with 5-, -3, all, /draft/ or *.jpg, left out with !3 or ^4-6, and ~ inverts:")
+		r.println("Enter 'c' and a number to use a folder name found, or 'n' and a name of your own (e.g., c2 or n Reports):")
+		r.println("Enter 'd' and a folder to create it in (e.g., d Sorted), 't' to toggle dry run, 'p' to preview or 'q' to quit:")
 		for {
-			fmt.Print("Your choice: ")
-			input, err := reader.ReadString('\n')
+			r.printf("Your choice: ")
+			input, err := r.in.ReadString('\n')
+			if errors.Is(err, io.EOF) {
+				return nil, "", mvcommon.ErrNoSelection
+			}
 			if err != nil {
-				fmt.Printf("Error reading input: %v\n", err)
-				panic(err)
+				return nil, "", fmt.Errorf("failed to read selection: %w", err)
 			}
 			input = strings.TrimSpace(input)
 
 			if input == "a" {
-				return selectedFiles, folderName // Confirm all files
+				return leaveOutUnmatched(r, selectedFiles, keys, active), folderName, nil // Confirm all files
 			}
 
 			if input == "r" {
 				nextSelectedFiles = files
+				chosenName = ""
 				break
 			}
 
-			selectedIndices, err := mvcommon.ParseNumberRanges(input, len(selectedFiles))
+			if input == "q" {
+				return nil, "", errQuit
+			}
+
+			if input == "t" {
+				session.dryRun = !session.dryRun
+				if session.dryRun {
+					r.println("Dry run on: no files will be changed")
+				} else {
+					r.println("Dry run off: files will be moved")
+				}
+				continue
+			}
+
+			if input == "p" {
+				previewPlan(r, session, folderName, selectedFiles)
+				continue
+			}
+
+			if input == "d" || strings.HasPrefix(input, "d ") {
+				// A bare 'd' goes back to creating the folder where --parents puts it.
+				session.plan.Dest = strings.TrimSpace(strings.TrimPrefix(input, "d"))
+				nextSelectedFiles = selectedFiles
+				break
+			}
+
+			if name, ok := strings.CutPrefix(input, "n "); ok {
+				if name = strings.TrimSpace(name); name != "" {
+					chosenName = name
+					nextSelectedFiles = selectedFiles
+					break
+				}
+				r.println("Invalid input: empty folder name")
+				continue
+			}
+
+			if m := candidateChoice.FindStringSubmatch(input); m != nil {
+				i, err := strconv.Atoi(m[1])
+				if err != nil || i < 1 || i > len(candidates) {
+					r.printf("Invalid input: no folder name %q\n", input)
+					continue
+				}
+				chosenName = candidates[i-1]
+				nextSelectedFiles = selectedFiles
+				break
+			}
+
+			selectedIndices, err := mvcommon.ParseSelection(input, selectedFiles)
 			if err != nil {
-				fmt.Println("Invalid input:", err)
+				r.println("Invalid input:", err)
 				continue
 			}
 
@@ -123,14 +502,98 @@ func interactiveFileSelection(files []string, stopWords []string, trim string, m
 				break
 			}
 
-			fmt.Println("No valid files selected. Try again.")
+			r.println("No valid files selected. Try again.")
 		}
 		selectedFiles = nextSelectedFiles
 	}
 }
 
-func Usage() {
+// previewPlan prints the operations moving files to folderName would take with the settings of session.
+func previewPlan(r *reporter, session *interactiveSession, folderName string, files []string) {
+	if folderName == "" {
+		r.println("Nothing to preview: no folder name, enter one with 'n'")
+		return
+	}
+	plan, err := planGroups([]mvcommon.Group{{Prefix: folderName, Names: files}}, session.plan, session.policy)
+	if err != nil {
+		r.println("Cannot preview:", err)
+		return
+	}
+	if err := plan.WriteDryRun(r.human); err != nil {
+		r.println("Cannot preview:", err)
+	}
+}
+
+// detectFlags are the flags configuring detection, shared by the commands that detect prefixes.
+type detectFlags struct {
+	stopWords  string
+	trim       string
+	minMatch   int
+	normalize  string
+	ignoreCase bool
+	tokens     bool
+	anchor     string
+	keepExt    bool
+	minShare   float64
+	strategy   string
+}
+
+// detector returns the Detector configured by the flags.
+func (f detectFlags) detector() (*mvcommon.Detector, error) {
+	normalization, err := mvcommon.ParseNormalization(f.normalize)
+	if err != nil {
+		return nil, err
+	}
+
+	anchor, err := mvcommon.ParseAnchor(f.anchor)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy, err := mvcommon.ParseStrategy(f.strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.minShare <= 0 || f.minShare > 1 {
+		return nil, fmt.Errorf("invalid min share %v, expected a fraction above 0 and at most 1", f.minShare)
+	}
+
+	opts := []mvcommon.Option{
+		mvcommon.WithStopWords(parseStopWords(f.stopWords)...),
+		mvcommon.WithTrim(f.trim),
+		mvcommon.WithMinLength(f.minMatch),
+		mvcommon.WithNormalization(normalization),
+		mvcommon.WithFoldCase(f.ignoreCase),
+		mvcommon.WithAnchor(anchor),
+		mvcommon.WithKeepExtension(f.keepExt),
+		mvcommon.WithMinShare(f.minShare),
+		mvcommon.WithScorer(strategy.Scorer()),
+	}
+	if f.tokens {
+		opts = append(opts, mvcommon.WithTokenizer(mvcommon.Words))
+	}
+	return mvcommon.NewDetector(opts...), nil
+}
+
+// parseStopWords parses the comma separated --stopword flag, an empty value selects the default stop words.
+func parseStopWords(s string) []string {
+	if s == "" {
+		return mvcommon.DefaultStopWords
+	}
+	return strings.Split(s, ",")
+}
+
+// splitList splits a comma separated flag value, an empty value is an empty list.
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func Usage(w io.Writer) {
 	stopWords := mvcommon.DefaultStopWords
 	trimFlag := mvcommon.DefaultTrim
-	fmt.Println("Usage: mvcommon [-stopword=<stopword:`" + strings.Join(stopWords, "`,`") + "`>] [-trim=<trim:" + trimFlag + ">] [-min=3] [-dry-run] [-interactive] <file1> <file2> ...")
+	fmt.Fprintln(w, "Usage: mvcommon [-stopword=<stopword:`"+strings.Join(stopWords, "`,`")+"`>] [-trim=<trim:"+trimFlag+">] [-min=3] [-normalize=none] [-ignore-case] [-tokens] [-anchor=any] [-keep-ext] [-min-share=1] [-strategy=auto] [-pattern=<regexp>] [-dry-run] [-interactive] [-tui] [-cluster] [-scan=<dir> [-recursive] [-max-depth=0] [-include=<globs>] [-exclude=<globs>] [-hidden]] [-on-conflict=fail] [-match-on=base] [-parents=common] [-dest=<dir>] [-folder-template={{.Prefix}}] [-group-by-ext] [-verify=size] [-xattrs] [-output=text] <file1> <file2> ...")
 }
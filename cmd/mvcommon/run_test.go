@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/arran4/mvcommon"
+)
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		pattern  string
+		output   string
+		expected error
+	}{
+		{name: "TooFewFiles", files: []string{"only.txt"}, output: outputJSON, expected: mvcommon.ErrTooFewFiles},
+		{name: "NoCommonPrefix", files: []string{"alpha", "xyz"}, output: outputJSON, expected: mvcommon.ErrNoCommonPrefix},
+		{name: "PatternNoMatch", files: []string{"alpha", "xyz"}, pattern: `(?P<project>[A-Z]{3}-\d+)`, output: outputJSON, expected: mvcommon.ErrNoCommonPrefix},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Run("", "", 3, "none", false, false, "any", false, 1, "auto", test.pattern, true, false, false, false, "", false, 0, "", "", false, "fail", "base", "common", "", mvcommon.DefaultFolderTemplate, false, "size", false, test.output, test.files...)
+			if !errors.Is(err, test.expected) {
+				t.Errorf("Run(%q) got %v; want %v", test.files, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestRootExecuteReturnsRunError(t *testing.T) {
+	cmd, err := NewRoot("test", "", "", "")
+	if err != nil {
+		t.Fatalf("Failed to create root command: %v", err)
+	}
+	err = cmd.Execute([]string{"-dry-run", "-output", "json", "alpha", "xyz"})
+	if !errors.Is(err, mvcommon.ErrNoCommonPrefix) {
+		t.Errorf("Execute() got %v; want %v", err, mvcommon.ErrNoCommonPrefix)
+	}
+	if got := exitCode(err); got != exitNoCommonPrefix {
+		t.Errorf("exitCode() got %d; want %d", got, exitNoCommonPrefix)
+	}
+}
+
+func TestDetectSingleFolderLeavesOutUnmatched(t *testing.T) {
+	r, err := newReporter(outputJSON, true)
+	if err != nil {
+		t.Fatalf("Failed to create reporter: %v", err)
+	}
+	r.human, r.out = io.Discard, io.Discard
+	files := []string{filepath.Join("a", "Report 234 - Draft.txt"), filepath.Join("b", "IMG_0001.jpg"), filepath.Join("a", "Report 234 - Final.txt")}
+	detector := mvcommon.NewDetector(mvcommon.WithTrim("_- "), mvcommon.WithMinLength(3), mvcommon.WithMinShare(0.6))
+
+	groups, err := detectSingleFolder(r, detector, mvcommon.MatchBase, nil, files)
+	if err != nil {
+		t.Fatalf("detectSingleFolder failed: %v", err)
+	}
+	expected := []mvcommon.Group{{Prefix: "Report 234", Names: []string{files[0], files[2]}}}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("detectSingleFolder() got %#v; want %#v", groups, expected)
+	}
+	if !reflect.DeepEqual(r.report.Unmatched, []string{files[1]}) {
+		t.Errorf("Unmatched got %q; want %q", r.report.Unmatched, []string{files[1]})
+	}
+}
+
+func TestInteractiveFileSelectionFolderName(t *testing.T) {
+	files := []string{"Report 234 - Draft1 notes.txt", "Report 234 - Draft2 notes.txt", "Summary - Draft3 notes.txt"}
+	tests := []struct {
+		name          string
+		input         string
+		expectedFiles []string
+		expectedName  string
+	}{
+		{name: "Default", input: "a\n", expectedFiles: files, expectedName: "Draft"},
+		{name: "Candidate", input: "c2\na\n", expectedFiles: files, expectedName: "notes"},
+		{name: "InvalidCandidate", input: "c9\nc0\na\n", expectedFiles: files, expectedName: "Draft"},
+		{name: "Custom", input: "n My Reports\na\n", expectedFiles: files, expectedName: "My Reports"},
+		{name: "KeptOverSelection", input: "n My Reports\n1-2\na\n", expectedFiles: files[:2], expectedName: "My Reports"},
+		{name: "Reset", input: "n My Reports\nr\na\n", expectedFiles: files, expectedName: "Draft"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := newReporter(outputText, true)
+			if err != nil {
+				t.Fatalf("Failed to create reporter: %v", err)
+			}
+			r.human, r.out = io.Discard, io.Discard
+			r.in = bufio.NewReader(strings.NewReader(test.input))
+			detector := mvcommon.NewDetector(mvcommon.WithTrim("_- "), mvcommon.WithMinLength(3))
+
+			selected, folderName, err := interactiveFileSelection(r, &interactiveSession{}, files, detector, mvcommon.MatchBase)
+			if err != nil {
+				t.Fatalf("interactiveFileSelection failed: %v", err)
+			}
+			if !reflect.DeepEqual(selected, test.expectedFiles) || folderName != test.expectedName {
+				t.Errorf("interactiveFileSelection() got (%q, %q); want (%q, %q)", selected, folderName, test.expectedFiles, test.expectedName)
+			}
+		})
+	}
+}
+
+func TestInteractiveFileSelectionEndOfInput(t *testing.T) {
+	r, err := newReporter(outputText, true)
+	if err != nil {
+		t.Fatalf("Failed to create reporter: %v", err)
+	}
+	r.human, r.out = io.Discard, io.Discard
+	r.in = bufio.NewReader(strings.NewReader("c1\n"))
+
+	_, _, err = interactiveFileSelection(r, &interactiveSession{}, []string{"Report 1.txt", "Report 2.txt"}, mvcommon.NewDetector(), mvcommon.MatchBase)
+	if !errors.Is(err, mvcommon.ErrNoSelection) {
+		t.Errorf("interactiveFileSelection() got error %v; want %v", err, mvcommon.ErrNoSelection)
+	}
+}
+
+func TestInteractiveFileSelectionSession(t *testing.T) {
+	files := []string{"Report 234 - Draft1.txt", "Report 234 - Draft2.txt"}
+	tests := []struct {
+		name           string
+		input          string
+		expectedDest   string
+		expectedDryRun bool
+		expectedErr    error
+		expectedOutput string
+	}{
+		{name: "Destination", input: "d Sorted\na\n", expectedDest: "Sorted"},
+		{name: "DestinationCleared", input: "d Sorted\nd\na\n", expectedDest: ""},
+		{name: "ToggleDryRun", input: "t\na\n", expectedDryRun: true},
+		{name: "ToggleDryRunTwice", input: "t\nt\na\n", expectedDryRun: false},
+		{name: "Preview", input: "d Sorted\np\na\n", expectedDest: "Sorted", expectedOutput: "Would move Report 234 - Draft1.txt -> " + filepath.Join("Sorted", "Report 234", "Report 234 - Draft1.txt")},
+		{name: "PreviewRenamed", input: "n Reports\np\na\n", expectedOutput: "Would create folder: Reports"},
+		{name: "Quit", input: "d Sorted\nq\n", expectedDest: "Sorted", expectedErr: errQuit},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := newReporter(outputText, false)
+			if err != nil {
+				t.Fatalf("Failed to create reporter: %v", err)
+			}
+			var human strings.Builder
+			r.human, r.out = &human, io.Discard
+			r.in = bufio.NewReader(strings.NewReader(test.input))
+			session := &interactiveSession{plan: mvcommon.PlanOptions{Parents: mvcommon.ParentsCommon}, policy: mvcommon.ConflictFail}
+			detector := mvcommon.NewDetector(mvcommon.WithTrim("_- "), mvcommon.WithMinLength(3))
+
+			_, _, err = interactiveFileSelection(r, session, files, detector, mvcommon.MatchBase)
+			if !errors.Is(err, test.expectedErr) {
+				t.Fatalf("interactiveFileSelection() got error %v; want %v", err, test.expectedErr)
+			}
+			if session.plan.Dest != test.expectedDest || session.dryRun != test.expectedDryRun {
+				t.Errorf("interactiveFileSelection() left (%q, %v); want (%q, %v)", session.plan.Dest, session.dryRun, test.expectedDest, test.expectedDryRun)
+			}
+			if !strings.Contains(human.String(), test.expectedOutput) {
+				t.Errorf("interactiveFileSelection() printed %q; want it to contain %q", human.String(), test.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestInteractiveFileSelectionSelectors(t *testing.T) {
+	files := []string{"Report 234 - Draft1.txt", "Report 234 - Draft2.txt", "Report 234 - Final.txt", "photo.jpg"}
+	r, err := newReporter(outputText, true)
+	if err != nil {
+		t.Fatalf("Failed to create reporter: %v", err)
+	}
+	r.human, r.out = io.Discard, io.Discard
+	r.in = bufio.NewReader(strings.NewReader("!*.jpg,^/final/\na\n"))
+	detector := mvcommon.NewDetector(mvcommon.WithTrim("_- "), mvcommon.WithMinLength(3))
+
+	selected, folderName, err := interactiveFileSelection(r, &interactiveSession{}, files, detector, mvcommon.MatchBase)
+	if err != nil {
+		t.Fatalf("interactiveFileSelection failed: %v", err)
+	}
+	if !reflect.DeepEqual(selected, files[:2]) || folderName != "Report 234" {
+		t.Errorf("interactiveFileSelection() got (%q, %q); want (%q, %q)", selected, folderName, files[:2], "Report 234")
+	}
+}
+
+func TestInteractiveFileSelectionLeavesOutUnmatched(t *testing.T) {
+	files := []string{"Show - 01.mkv", "Show - 02.mkv", "zzz.txt"}
+	r, err := newReporter(outputJSON, true)
+	if err != nil {
+		t.Fatalf("Failed to create reporter: %v", err)
+	}
+	var human strings.Builder
+	r.human, r.out = &human, io.Discard
+	r.in = bufio.NewReader(strings.NewReader("a\n"))
+	detector := mvcommon.NewDetector(mvcommon.WithTrim("_- "), mvcommon.WithMinLength(3), mvcommon.WithMinShare(0.6))
+
+	groups, err := detectSingleFolder(r, detector, mvcommon.MatchBase, &interactiveSession{}, files)
+	if err != nil {
+		t.Fatalf("detectSingleFolder failed: %v", err)
+	}
+	expected := []mvcommon.Group{{Prefix: "Show", Names: files[:2]}}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("detectSingleFolder() got %#v; want %#v", groups, expected)
+	}
+	if !reflect.DeepEqual(r.report.Unmatched, files[2:]) {
+		t.Errorf("Unmatched got %q; want %q", r.report.Unmatched, files[2:])
+	}
+	if want := `3. zzz.txt (left out, does not share "Show")`; !strings.Contains(human.String(), want) {
+		t.Errorf("prompt got %q; want it to contain %q", human.String(), want)
+	}
+}
+
+func TestInteractiveFileSelectionSelectorStartingWithC(t *testing.T) {
+	files := []string{"clip 01.mkv", "cover.jpg", "clip 02.mkv"}
+	r, err := newReporter(outputText, true)
+	if err != nil {
+		t.Fatalf("Failed to create reporter: %v", err)
+	}
+	r.human, r.out = io.Discard, io.Discard
+	r.in = bufio.NewReader(strings.NewReader("c*.mkv\na\n"))
+	detector := mvcommon.NewDetector(mvcommon.WithTrim("_- "), mvcommon.WithMinLength(3))
+
+	selected, folderName, err := interactiveFileSelection(r, &interactiveSession{}, files, detector, mvcommon.MatchBase)
+	if err != nil {
+		t.Fatalf("interactiveFileSelection failed: %v", err)
+	}
+	expected := []string{"clip 01.mkv", "clip 02.mkv"}
+	if !reflect.DeepEqual(selected, expected) || folderName != "clip 0" {
+		t.Errorf("interactiveFileSelection() got (%q, %q); want (%q, %q)", selected, folderName, expected, "clip 0")
+	}
+}
@@ -0,0 +1,590 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/arran4/mvcommon"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/uniseg"
+)
+
+// tuiFile is a file listed by the TUI.
+type tuiFile struct {
+	path     string
+	included bool
+}
+
+// tuiGroup is a folder and its files in the TUI. The last group has no folder, it holds the files left in place.
+type tuiGroup struct {
+	name string
+	// renamed is set when the user named the folder, the name is then kept when its files change.
+	renamed bool
+	files   []tuiFile
+}
+
+// tuiRow is a line of the file list, the header of a group when file is -1 and otherwise one of its files.
+type tuiRow struct {
+	group int
+	file  int
+}
+
+type tuiMode int
+
+const (
+	tuiBrowse tuiMode = iota
+	tuiMoving
+	tuiRenaming
+	tuiConfirm
+)
+
+// tui reviews the detected groups on a full-screen terminal before they are planned.
+type tui struct {
+	screen  tcell.Screen
+	session *interactiveSession
+	// detect names a folder from the files included in it, nil keeps the names as they are.
+	detect func(files []string) string
+	groups []tuiGroup
+	rows   []tuiRow
+	cursor int
+	// top is the first row of the list shown.
+	top    int
+	mode   tuiMode
+	moving tuiRow
+	edit   []rune
+	// plan is what confirming moves, set on the confirm screen, and scroll the first of its operations shown.
+	plan   *mvcommon.Plan
+	scroll int
+	status string
+	done   bool
+	quit   bool
+}
+
+// reviewInTUI groups the files the way the flags ask for and lets the user review the groups on a full-screen
+// terminal. Files left in place are reported as unmatched.
+func reviewInTUI(r *reporter, session *interactiveSession, detector *mvcommon.Detector, pattern string, cluster bool, matchOn mvcommon.MatchOn, files []string) ([]mvcommon.Group, error) {
+	groups, detect, err := tuiGroups(detector, pattern, cluster, matchOn, files)
+	if err != nil {
+		return nil, err
+	}
+
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open terminal: %w", err)
+	}
+	if err := screen.Init(); err != nil {
+		return nil, fmt.Errorf("failed to open terminal: %w", err)
+	}
+	groups, err = reviewGroups(screen, session, groups, detect)
+	screen.Fini()
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []mvcommon.Group
+	for _, group := range groups {
+		if group.Prefix == "" {
+			for _, file := range group.Names {
+				r.printf("Skipping %s: left in place\n", file)
+				r.unmatched(file)
+			}
+			continue
+		}
+		selected = append(selected, group)
+	}
+	if len(selected) == 0 {
+		return nil, mvcommon.ErrNoSelection
+	}
+	return selected, nil
+}
+
+// tuiGroups detects the groups the TUI starts with, ending with a group without a Prefix when some files are left
+// out, and the function naming a group again when its files change.
+func tuiGroups(detector *mvcommon.Detector, pattern string, cluster bool, matchOn mvcommon.MatchOn, files []string) ([]mvcommon.Group, func([]string) string, error) {
+	detect := func(files []string) string {
+		result, err := detector.Detect(mvcommon.MatchKeys(files, matchOn))
+		if err != nil {
+			return ""
+		}
+		return result.Prefix
+	}
+
+	switch {
+	case pattern != "":
+		re, err := mvcommon.ParsePattern(pattern)
+		if err != nil {
+			return nil, nil, NewUserError(err, "invalid --pattern")
+		}
+		// A pattern names the groups, there is nothing to detect.
+		return mvcommon.GroupByPattern(files, re, matchOn), nil, nil
+	case cluster:
+		return mvcommon.ClusterByPrefix(files, mvcommon.ClusterOptions{
+			Options: detector.Options(),
+			MatchOn: matchOn,
+		}), detect, nil
+	}
+
+	keys := mvcommon.MatchKeys(files, matchOn)
+	result, err := detector.Detect(keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	matched := mvcommon.Group{Prefix: result.Prefix}
+	var unmatched []string
+	for i, file := range files {
+		if slices.Contains(result.Unmatched, keys[i]) {
+			unmatched = append(unmatched, file)
+			continue
+		}
+		matched.Names = append(matched.Names, file)
+	}
+	groups := []mvcommon.Group{matched}
+	if len(unmatched) > 0 {
+		groups = append(groups, mvcommon.Group{Names: unmatched})
+	}
+	return groups, detect, nil
+}
+
+// reviewGroups shows groups on screen until the user confirms or quits, returning the groups to move with a final
+// group without a Prefix holding the files left in place. The screen must be initialised.
+func reviewGroups(screen tcell.Screen, session *interactiveSession, groups []mvcommon.Group, detect func([]string) string) ([]mvcommon.Group, error) {
+	t := newTUI(screen, session, groups, detect)
+	for !t.done {
+		t.draw()
+		ev := screen.PollEvent()
+		if ev == nil {
+			return nil, errQuit
+		}
+		t.handle(ev)
+	}
+	if t.quit {
+		return nil, errQuit
+	}
+	return t.result(), nil
+}
+
+func newTUI(screen tcell.Screen, session *interactiveSession, groups []mvcommon.Group, detect func([]string) string) *tui {
+	t := &tui{screen: screen, session: session, detect: detect}
+	var left []tuiFile
+	for _, group := range groups {
+		if group.Prefix == "" {
+			for _, name := range group.Names {
+				left = append(left, tuiFile{path: name})
+			}
+			continue
+		}
+		g := tuiGroup{name: group.Prefix}
+		for _, name := range group.Names {
+			g.files = append(g.files, tuiFile{path: name, included: true})
+		}
+		t.groups = append(t.groups, g)
+	}
+	t.groups = append(t.groups, tuiGroup{files: left})
+	t.layout()
+	return t
+}
+
+// leftover reports whether g is the group of files left in place.
+func (t *tui) leftover(g int) bool {
+	return g == len(t.groups)-1
+}
+
+// layout lists the rows of the groups.
+func (t *tui) layout() {
+	t.rows = t.rows[:0]
+	for g, group := range t.groups {
+		t.rows = append(t.rows, tuiRow{group: g, file: -1})
+		for f := range group.files {
+			t.rows = append(t.rows, tuiRow{group: g, file: f})
+		}
+	}
+	t.cursor = min(t.cursor, len(t.rows)-1)
+}
+
+// redetect names group g again from its included files, unless the user named it. The name is kept when nothing is
+// found in common, such as after moving in a file that does not share it.
+func (t *tui) redetect(g int) {
+	group := &t.groups[g]
+	if t.detect == nil || t.leftover(g) || group.renamed {
+		return
+	}
+	var files []string
+	for _, file := range group.files {
+		if file.included {
+			files = append(files, file.path)
+		}
+	}
+	if len(files) == 0 {
+		return
+	}
+	if name := t.detect(files); name != "" {
+		group.name = name
+	}
+}
+
+// result returns the groups with the files to move, and a group without a Prefix for the others.
+func (t *tui) result() []mvcommon.Group {
+	var groups []mvcommon.Group
+	var left []string
+	for g, group := range t.groups {
+		var names []string
+		for _, file := range group.files {
+			if file.included && !t.leftover(g) {
+				names = append(names, file.path)
+			} else {
+				left = append(left, file.path)
+			}
+		}
+		if len(names) > 0 {
+			groups = append(groups, mvcommon.Group{Prefix: group.name, Names: names})
+		}
+	}
+	if len(left) > 0 {
+		groups = append(groups, mvcommon.Group{Names: left})
+	}
+	return groups
+}
+
+func (t *tui) handle(ev tcell.Event) {
+	switch ev := ev.(type) {
+	case *tcell.EventResize:
+		t.screen.Sync()
+	case *tcell.EventKey:
+		t.status = ""
+		switch t.mode {
+		case tuiBrowse:
+			t.browseKey(ev)
+		case tuiMoving:
+			t.movingKey(ev)
+		case tuiRenaming:
+			t.renamingKey(ev)
+		case tuiConfirm:
+			t.confirmKey(ev)
+		}
+	}
+}
+
+// navigate moves pos over n lines for the keys that scroll, reporting whether ev was one of them.
+func (t *tui) navigate(ev *tcell.EventKey, pos *int, n int) bool {
+	_, height := t.screen.Size()
+	page := max(height-3, 1)
+	switch {
+	case ev.Key() == tcell.KeyUp || ev.Rune() == 'k':
+		*pos--
+	case ev.Key() == tcell.KeyDown || ev.Rune() == 'j':
+		*pos++
+	case ev.Key() == tcell.KeyPgUp:
+		*pos -= page
+	case ev.Key() == tcell.KeyPgDn:
+		*pos += page
+	case ev.Key() == tcell.KeyHome:
+		*pos = 0
+	case ev.Key() == tcell.KeyEnd:
+		*pos = n - 1
+	default:
+		return false
+	}
+	*pos = max(min(*pos, n-1), 0)
+	return true
+}
+
+func (t *tui) browseKey(ev *tcell.EventKey) {
+	if t.navigate(ev, &t.cursor, len(t.rows)) {
+		return
+	}
+	row := t.rows[t.cursor]
+	switch {
+	case ev.Key() == tcell.KeyEscape || ev.Rune() == 'q':
+		t.quit, t.done = true, true
+	case ev.Rune() == ' ':
+		t.toggle(row)
+	case ev.Rune() == 'm':
+		if row.file < 0 {
+			t.status = "Select a file to move"
+			return
+		}
+		t.mode, t.moving = tuiMoving, row
+		t.status = fmt.Sprintf("Moving %s: select a folder and press Enter, Esc cancels", t.groups[row.group].files[row.file].path)
+	case ev.Rune() == 'r':
+		if t.leftover(row.group) {
+			t.status = "Files left in place have no folder to rename"
+			return
+		}
+		t.mode, t.edit = tuiRenaming, []rune(t.groups[row.group].name)
+	case ev.Rune() == 't':
+		t.session.dryRun = !t.session.dryRun
+	case ev.Key() == tcell.KeyEnter || ev.Rune() == 'c':
+		t.review()
+	}
+}
+
+// toggle includes or leaves out the file of row, or all files of its group for a header.
+func (t *tui) toggle(row tuiRow) {
+	if t.leftover(row.group) {
+		t.status = "Files left in place are not moved, move them to a folder with m"
+		return
+	}
+	files := t.groups[row.group].files
+	if row.file >= 0 {
+		files[row.file].included = !files[row.file].included
+	} else {
+		all := !slices.ContainsFunc(files, func(f tuiFile) bool { return !f.included })
+		for i := range files {
+			files[i].included = !all
+		}
+	}
+	t.redetect(row.group)
+}
+
+func (t *tui) movingKey(ev *tcell.EventKey) {
+	if t.navigate(ev, &t.cursor, len(t.rows)) {
+		return
+	}
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		t.mode = tuiBrowse
+	case tcell.KeyEnter:
+		from, to := t.moving.group, t.rows[t.cursor].group
+		t.mode = tuiBrowse
+		if from == to {
+			return
+		}
+		file := t.groups[from].files[t.moving.file]
+		file.included = !t.leftover(to)
+		t.groups[from].files = slices.Delete(t.groups[from].files, t.moving.file, t.moving.file+1)
+		t.groups[to].files = append(t.groups[to].files, file)
+		t.redetect(from)
+		t.redetect(to)
+		t.layout()
+		t.cursor = slices.Index(t.rows, tuiRow{group: to, file: len(t.groups[to].files) - 1})
+	default:
+		t.status = fmt.Sprintf("Moving %s: select a folder and press Enter, Esc cancels", t.groups[t.moving.group].files[t.moving.file].path)
+	}
+}
+
+func (t *tui) renamingKey(ev *tcell.EventKey) {
+	group := &t.groups[t.rows[t.cursor].group]
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		t.mode = tuiBrowse
+	case tcell.KeyEnter:
+		t.mode = tuiBrowse
+		name := strings.TrimSpace(string(t.edit))
+		if name == "" {
+			// Clearing the name goes back to the detected one.
+			group.renamed = false
+			t.redetect(t.rows[t.cursor].group)
+			return
+		}
+		group.name, group.renamed = name, true
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(t.edit) > 0 {
+			t.edit = t.edit[:len(t.edit)-1]
+		}
+	case tcell.KeyRune:
+		t.edit = append(t.edit, ev.Rune())
+	}
+}
+
+// review opens the confirm screen when every folder with files to move has a name and the plan can be made.
+func (t *tui) review() {
+	var groups []mvcommon.Group
+	for _, group := range t.result() {
+		if group.Prefix == "" {
+			continue
+		}
+		groups = append(groups, group)
+	}
+	if len(groups) == 0 {
+		t.status = "No files to move, include some with Space or quit with q"
+		return
+	}
+	for g, group := range t.groups {
+		if !t.leftover(g) && group.name == "" && slices.ContainsFunc(group.files, func(f tuiFile) bool { return f.included }) {
+			t.status = "A folder has no name, rename it with r"
+			return
+		}
+	}
+	plan, err := planGroups(groups, t.session.plan, t.session.policy)
+	if err != nil {
+		t.status = fmt.Sprintf("Cannot move the files: %v", err)
+		return
+	}
+	t.mode, t.plan, t.scroll = tuiConfirm, plan, 0
+}
+
+func (t *tui) confirmKey(ev *tcell.EventKey) {
+	if t.navigate(ev, &t.scroll, len(t.plan.Operations)) {
+		return
+	}
+	switch {
+	case ev.Rune() == 'y' || ev.Key() == tcell.KeyEnter:
+		t.done = true
+	case ev.Rune() == 'n' || ev.Key() == tcell.KeyEscape:
+		t.mode = tuiBrowse
+	case ev.Rune() == 't':
+		t.session.dryRun = !t.session.dryRun
+	}
+}
+
+var (
+	tuiStyle       = tcell.StyleDefault
+	tuiHeaderStyle = tcell.StyleDefault.Bold(true)
+	tuiCursorStyle = tcell.StyleDefault.Reverse(true)
+	tuiDimStyle    = tcell.StyleDefault.Dim(true)
+)
+
+func (t *tui) draw() {
+	t.screen.Clear()
+	width, height := t.screen.Size()
+
+	title := "mvcommon: review the folders"
+	if t.session.dryRun {
+		title += " (dry run)"
+	}
+	drawText(t.screen, 0, 0, width, tuiHeaderStyle, title)
+
+	if t.mode == tuiConfirm {
+		t.drawConfirm(width, height)
+	} else {
+		t.drawList(width, height)
+	}
+
+	help := "↑↓ move  Space include  m move to folder  r rename  t dry run  Enter confirm  q quit"
+	switch t.mode {
+	case tuiMoving:
+		help = "↑↓ select a folder  Enter move here  Esc cancel"
+	case tuiRenaming:
+		help = "Enter rename  Esc cancel  an empty name goes back to the detected one"
+	case tuiConfirm:
+		help = "↑↓ scroll  y move the files  n go back  t dry run"
+	}
+	if t.status != "" {
+		help = t.status
+	}
+	drawText(t.screen, 0, height-1, width, tuiDimStyle, help)
+	t.screen.Show()
+}
+
+func (t *tui) drawList(width, height int) {
+	lines := max(height-2, 1)
+	if t.cursor < t.top {
+		t.top = t.cursor
+	}
+	if t.cursor >= t.top+lines {
+		t.top = t.cursor - lines + 1
+	}
+
+	for y := 0; y < lines && t.top+y < len(t.rows); y++ {
+		i := t.top + y
+		row := t.rows[i]
+		group := t.groups[row.group]
+		style := tuiStyle
+		var text string
+		switch {
+		case row.file < 0 && t.leftover(row.group):
+			text, style = "Left in place", tuiHeaderStyle
+		case row.file < 0:
+			name := group.name
+			if t.mode == tuiRenaming && row.group == t.rows[t.cursor].group {
+				name = string(t.edit) + "_"
+			} else if name == "" {
+				name = "(no name)"
+			}
+			text, style = name+"/", tuiHeaderStyle
+		default:
+			file := group.files[row.file]
+			mark := "[ ]"
+			if t.leftover(row.group) {
+				mark = "   "
+			} else if file.included {
+				mark = "[x]"
+			}
+			if t.mode == tuiMoving && row == t.moving {
+				mark = ">>>"
+			}
+			text = "  " + mark + " " + file.path
+		}
+		if i == t.cursor {
+			style = tuiCursorStyle
+		}
+		drawText(t.screen, 0, y+1, width, style, text)
+	}
+}
+
+func (t *tui) drawConfirm(width, height int) {
+	y := 2
+	line := func(style tcell.Style, format string, a ...any) {
+		if y < height-1 {
+			drawText(t.screen, 0, y, width, style, fmt.Sprintf(format, a...))
+		}
+		y++
+	}
+
+	var folders, moves int
+	for _, op := range t.plan.Operations {
+		if op.Kind == mvcommon.OperationMove && !op.Skip {
+			moves++
+		}
+	}
+	for _, group := range t.result() {
+		if group.Prefix != "" {
+			folders++
+		}
+	}
+	if t.session.dryRun {
+		line(tuiHeaderStyle, "Dry run: would move %d files into %d folders", moves, folders)
+	} else {
+		line(tuiHeaderStyle, "Move %d files into %d folders?", moves, folders)
+	}
+	line(tuiStyle, "")
+
+	// The operations get the lines left above the help line, the first and last of them telling how many more there
+	// are when they do not all fit.
+	ops := t.plan.Operations
+	lines := max(height-1-y, 1)
+	t.scroll = max(min(t.scroll, len(ops)-lines), 0)
+	from, to := t.scroll, min(t.scroll+lines, len(ops))
+	if from > 0 {
+		line(tuiDimStyle, "… %d more above", from+1)
+		from++
+	}
+	below := len(ops) - to
+	if below > 0 {
+		to--
+		below++
+	}
+	for _, op := range ops[from:to] {
+		switch {
+		case op.Kind == mvcommon.OperationMkdir:
+			line(tuiStyle, "Create %s", op.Destination)
+		case op.Skip:
+			line(tuiDimStyle, "  Skip %s: %s", op.Source, op.Conflict)
+		default:
+			line(tuiStyle, "  %s -> %s", op.Source, op.Destination)
+		}
+	}
+	if below > 0 {
+		line(tuiDimStyle, "… %d more below", below)
+	}
+}
+
+// drawText draws s on row y from column x, cut off at width columns.
+func drawText(screen tcell.Screen, x, y, width int, style tcell.Style, s string) {
+	state := -1
+	for s != "" && x < width {
+		var cluster string
+		var w int
+		cluster, s, w, state = uniseg.FirstGraphemeClusterInString(s, state)
+		if x+w > width {
+			break
+		}
+		runes := []rune(cluster)
+		screen.SetContent(x, y, runes[0], runes[1:], style)
+		x += max(w, 1)
+	}
+	for ; x < width; x++ {
+		screen.SetContent(x, y, ' ', nil, style)
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/arran4/mvcommon"
+	"github.com/gdamore/tcell/v2"
+)
+
+// tuiTestGroups are the groups the TUI tests start from, the rows are:
+//
+//	0 Trip/  1 Paris 1  2 Paris 2  3 Rome 1  4 IMG_000/  5 IMG_0001  6 IMG_0002  7 Left in place  8 notes.md
+var tuiTestGroups = []mvcommon.Group{
+	{Prefix: "Trip", Names: []string{"Trip Paris 1.jpg", "Trip Paris 2.jpg", "Trip Rome 1.jpg"}},
+	{Prefix: "IMG_000", Names: []string{"IMG_0001.jpg", "IMG_0002.jpg"}},
+	{Names: []string{"notes.md"}},
+}
+
+func newTestScreen(t *testing.T) tcell.SimulationScreen {
+	t.Helper()
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("Failed to initialise screen: %v", err)
+	}
+	screen.SetSize(80, 12)
+	t.Cleanup(screen.Fini)
+	return screen
+}
+
+func tuiTestDetect(files []string) string {
+	result, err := mvcommon.NewDetector(mvcommon.WithTrim("_- "), mvcommon.WithMinLength(3)).Detect(mvcommon.MatchKeys(files, mvcommon.MatchBase))
+	if err != nil {
+		return ""
+	}
+	return result.Prefix
+}
+
+// keys returns the events of a key sequence, runes are typed and names of special keys are given in angle brackets.
+func keys(seq ...string) []*tcell.EventKey {
+	special := map[string]tcell.Key{
+		"<up>": tcell.KeyUp, "<down>": tcell.KeyDown, "<end>": tcell.KeyEnd, "<home>": tcell.KeyHome,
+		"<enter>": tcell.KeyEnter, "<esc>": tcell.KeyEscape, "<backspace>": tcell.KeyBackspace2,
+	}
+	var events []*tcell.EventKey
+	for _, s := range seq {
+		if key, ok := special[s]; ok {
+			events = append(events, tcell.NewEventKey(key, 0, tcell.ModNone))
+			continue
+		}
+		for _, r := range s {
+			events = append(events, tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+		}
+	}
+	return events
+}
+
+// screenLines returns the text on screen, a line per row without trailing spaces.
+func screenLines(screen tcell.SimulationScreen) []string {
+	cells, width, height := screen.GetContents()
+	lines := make([]string, height)
+	for y := range lines {
+		var line strings.Builder
+		for x := 0; x < width; x++ {
+			line.Write(cells[y*width+x].Bytes)
+		}
+		lines[y] = strings.TrimRight(line.String(), " ")
+	}
+	return lines
+}
+
+func TestTUIKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []*tcell.EventKey
+		expected []mvcommon.Group
+		dryRun   bool
+		quit     bool
+	}{
+		{
+			name:     "ConfirmAll",
+			keys:     keys("<enter>", "y"),
+			expected: tuiTestGroups,
+		},
+		{
+			name: "LeaveOutFile",
+			keys: keys("<down>", "<down>", "<down>", " ", "<enter>", "y"),
+			expected: []mvcommon.Group{
+				{Prefix: "Trip Paris", Names: []string{"Trip Paris 1.jpg", "Trip Paris 2.jpg"}},
+				tuiTestGroups[1],
+				{Names: []string{"Trip Rome 1.jpg", "notes.md"}},
+			},
+		},
+		{
+			name: "LeaveOutGroup",
+			keys: keys("<down>", "<down>", "<down>", "<down>", " ", "<enter>", "y"),
+			expected: []mvcommon.Group{
+				tuiTestGroups[0],
+				{Names: []string{"IMG_0001.jpg", "IMG_0002.jpg", "notes.md"}},
+			},
+		},
+		{
+			name:     "IncludeAgain",
+			keys:     keys("<down>", " ", " ", "<enter>", "y"),
+			expected: tuiTestGroups,
+		},
+		{
+			name: "MoveFile",
+			keys: keys("<end>", "m", "<home>", "<enter>", "<enter>", "y"),
+			expected: []mvcommon.Group{
+				{Prefix: "Trip", Names: []string{"Trip Paris 1.jpg", "Trip Paris 2.jpg", "Trip Rome 1.jpg", "notes.md"}},
+				tuiTestGroups[1],
+			},
+		},
+		{
+			name: "MoveFileToLeftInPlace",
+			keys: keys("<down>", "<down>", "<down>", "m", "<end>", "<enter>", "<enter>", "y"),
+			expected: []mvcommon.Group{
+				{Prefix: "Trip Paris", Names: []string{"Trip Paris 1.jpg", "Trip Paris 2.jpg"}},
+				tuiTestGroups[1],
+				{Names: []string{"notes.md", "Trip Rome 1.jpg"}},
+			},
+		},
+		{
+			name:     "MoveCancelled",
+			keys:     keys("<end>", "m", "<home>", "<esc>", "<enter>", "y"),
+			expected: tuiTestGroups,
+		},
+		{
+			name: "Rename",
+			keys: keys("r", "s", "<enter>", "<down>", " ", "<enter>", "y"),
+			expected: []mvcommon.Group{
+				{Prefix: "Trips", Names: []string{"Trip Paris 2.jpg", "Trip Rome 1.jpg"}},
+				tuiTestGroups[1],
+				{Names: []string{"Trip Paris 1.jpg", "notes.md"}},
+			},
+		},
+		{
+			name:     "RenameCleared",
+			keys:     keys("r", " x", "<enter>", "r", "<backspace>", "<backspace>", "<backspace>", "<backspace>", "<backspace>", "<backspace>", "<enter>", "<enter>", "y"),
+			expected: tuiTestGroups,
+		},
+		{
+			name:     "RenameCancelled",
+			keys:     keys("r", "x", "<esc>", "<enter>", "y"),
+			expected: tuiTestGroups,
+		},
+		{
+			name:     "DryRun",
+			keys:     keys("t", "<enter>", "y"),
+			expected: tuiTestGroups,
+			dryRun:   true,
+		},
+		{
+			name: "Quit",
+			keys: keys("q"),
+			quit: true,
+		},
+		{
+			name: "BackFromConfirm",
+			keys: keys("<enter>", "n", "<esc>"),
+			quit: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			session := &interactiveSession{plan: mvcommon.PlanOptions{Parents: mvcommon.ParentsCommon}, policy: mvcommon.ConflictFail}
+			ui := newTUI(newTestScreen(t), session, tuiTestGroups, tuiTestDetect)
+			for _, key := range test.keys {
+				if ui.done {
+					t.Fatalf("TUI finished before all keys were pressed")
+				}
+				ui.handle(key)
+				ui.draw()
+			}
+			if !ui.done || ui.quit != test.quit {
+				t.Fatalf("TUI got done %v, quit %v; want done, quit %v", ui.done, ui.quit, test.quit)
+			}
+			if test.quit {
+				return
+			}
+			if got := ui.result(); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("result() got %#v; want %#v", got, test.expected)
+			}
+			if session.dryRun != test.dryRun {
+				t.Errorf("dry run got %v; want %v", session.dryRun, test.dryRun)
+			}
+		})
+	}
+}
+
+func TestTUIConfirmNeedsName(t *testing.T) {
+	session := &interactiveSession{}
+	groups := []mvcommon.Group{{Prefix: "Report", Names: []string{"Report 1.txt", "Report 2.txt"}}}
+	ui := newTUI(newTestScreen(t), session, groups, nil)
+	ui.groups[0].name = ""
+	ui.handle(keys("<enter>")[0])
+	if ui.mode != tuiBrowse || ui.status == "" {
+		t.Errorf("confirming a folder without a name got mode %v, status %q; want to stay with a message", ui.mode, ui.status)
+	}
+}
+
+func TestTUIScreen(t *testing.T) {
+	screen := newTestScreen(t)
+	session := &interactiveSession{plan: mvcommon.PlanOptions{Parents: mvcommon.ParentsCommon}, policy: mvcommon.ConflictFail}
+	ui := newTUI(screen, session, tuiTestGroups, tuiTestDetect)
+	for _, key := range keys("<down>", "<down>", "<down>", " ") {
+		ui.handle(key)
+	}
+	ui.draw()
+
+	lines := screenLines(screen)
+	expected := []string{
+		"mvcommon: review the folders",
+		"Trip Paris/",
+		"  [x] Trip Paris 1.jpg",
+		"  [x] Trip Paris 2.jpg",
+		"  [ ] Trip Rome 1.jpg",
+		"IMG_000/",
+		"  [x] IMG_0001.jpg",
+		"  [x] IMG_0002.jpg",
+		"Left in place",
+		"      notes.md",
+	}
+	if !reflect.DeepEqual(lines[:len(expected)], expected) {
+		t.Errorf("screen got %q; want %q", lines[:len(expected)], expected)
+	}
+
+	ui.handle(keys("<enter>")[0])
+	ui.draw()
+	lines = screenLines(screen)
+	if lines[2] != "Move 4 files into 2 folders?" {
+		t.Errorf("confirm screen got %q; want %q", lines[2], "Move 4 files into 2 folders?")
+	}
+}
+
+func TestReviewGroups(t *testing.T) {
+	screen := newTestScreen(t)
+	for _, key := range keys("<down>", " ", "<enter>", "y") {
+		screen.InjectKey(key.Key(), key.Rune(), key.Modifiers())
+	}
+	session := &interactiveSession{plan: mvcommon.PlanOptions{Parents: mvcommon.ParentsCommon}, policy: mvcommon.ConflictFail}
+
+	groups, err := reviewGroups(screen, session, tuiTestGroups, tuiTestDetect)
+	if err != nil {
+		t.Fatalf("reviewGroups failed: %v", err)
+	}
+	expected := []mvcommon.Group{
+		{Prefix: "Trip", Names: []string{"Trip Paris 2.jpg", "Trip Rome 1.jpg"}},
+		tuiTestGroups[1],
+		{Names: []string{"Trip Paris 1.jpg", "notes.md"}},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("reviewGroups() got %#v; want %#v", groups, expected)
+	}
+
+	screen.InjectKey(tcell.KeyRune, 'q', tcell.ModNone)
+	if _, err := reviewGroups(screen, session, tuiTestGroups, tuiTestDetect); !errors.Is(err, errQuit) {
+		t.Errorf("reviewGroups() got error %v; want %v", err, errQuit)
+	}
+}
+
+func TestTUIConfirmScrolls(t *testing.T) {
+	var files []string
+	for i := 1; i <= 20; i++ {
+		files = append(files, fmt.Sprintf("Trip Paris %02d.jpg", i))
+	}
+	screen := newTestScreen(t)
+	session := &interactiveSession{plan: mvcommon.PlanOptions{Parents: mvcommon.ParentsCommon}, policy: mvcommon.ConflictFail}
+	ui := newTUI(screen, session, []mvcommon.Group{{Prefix: "Trip Paris", Names: files}}, tuiTestDetect)
+	ui.handle(keys("<enter>")[0])
+
+	tests := []struct {
+		name     string
+		keys     []string
+		expected []string
+	}{
+		{
+			name: "Top",
+			expected: []string{
+				"Move 20 files into 1 folders?",
+				"",
+				"Create Trip Paris",
+				"  Trip Paris 01.jpg -> Trip Paris/Trip Paris 01.jpg",
+				"  Trip Paris 02.jpg -> Trip Paris/Trip Paris 02.jpg",
+				"  Trip Paris 03.jpg -> Trip Paris/Trip Paris 03.jpg",
+				"  Trip Paris 04.jpg -> Trip Paris/Trip Paris 04.jpg",
+				"  Trip Paris 05.jpg -> Trip Paris/Trip Paris 05.jpg",
+				"… 15 more below",
+			},
+		},
+		{
+			name: "Down",
+			keys: []string{"<down>", "<down>"},
+			expected: []string{
+				"Move 20 files into 1 folders?",
+				"",
+				"… 3 more above",
+				"  Trip Paris 03.jpg -> Trip Paris/Trip Paris 03.jpg",
+				"  Trip Paris 04.jpg -> Trip Paris/Trip Paris 04.jpg",
+				"  Trip Paris 05.jpg -> Trip Paris/Trip Paris 05.jpg",
+				"  Trip Paris 06.jpg -> Trip Paris/Trip Paris 06.jpg",
+				"  Trip Paris 07.jpg -> Trip Paris/Trip Paris 07.jpg",
+				"… 13 more below",
+			},
+		},
+		{
+			name: "End",
+			keys: []string{"<end>"},
+			expected: []string{
+				"Move 20 files into 1 folders?",
+				"",
+				"… 15 more above",
+				"  Trip Paris 15.jpg -> Trip Paris/Trip Paris 15.jpg",
+				"  Trip Paris 16.jpg -> Trip Paris/Trip Paris 16.jpg",
+				"  Trip Paris 17.jpg -> Trip Paris/Trip Paris 17.jpg",
+				"  Trip Paris 18.jpg -> Trip Paris/Trip Paris 18.jpg",
+				"  Trip Paris 19.jpg -> Trip Paris/Trip Paris 19.jpg",
+				"  Trip Paris 20.jpg -> Trip Paris/Trip Paris 20.jpg",
+			},
+		},
+		{
+			name:     "UpFromEnd",
+			keys:     []string{"<end>", "<up>"},
+			expected: []string{"Move 20 files into 1 folders?", "", "… 14 more above"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ui.handle(keys("<home>")[0])
+			ui.draw()
+			for _, key := range keys(tt.keys...) {
+				ui.handle(key)
+				ui.draw()
+			}
+			lines := screenLines(screen)[2:]
+			if !reflect.DeepEqual(lines[:len(tt.expected)], tt.expected) {
+				t.Errorf("confirm screen got %q; want %q", lines[:len(tt.expected)], tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/arran4/mvcommon"
+	"os"
+)
+
+// Undo is a subcommand `mvcommon undo`
+//
+// Flags:
+//
+//	runID:	--run		Run ID to undo, defaults to the last run
+//	dryRun:	--dry-run	Show what would be restored without moving files
+func Undo(runID string, dryRun bool) error {
+	path, err := mvcommon.DefaultJournalPath()
+	if err != nil {
+		return err
+	}
+
+	undone, err := mvcommon.Undo(path, runID, mvcommon.UndoOptions{DryRun: dryRun, Out: os.Stdout})
+	if errors.Is(err, mvcommon.ErrNothingToUndo) {
+		fmt.Println("Nothing to undo.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("[Dry Run] Would undo run %s\n", undone)
+	} else {
+		fmt.Printf("Undid run %s\n", undone)
+	}
+	return nil
+}
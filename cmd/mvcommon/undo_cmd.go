@@ -0,0 +1,53 @@
+// Generated by github.com/arran4/go-subcommand/cmd/gosubc
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+type UndoCmd struct {
+	*flag.FlagSet
+	parent        *RootCmd
+	runID         string
+	dryRun        bool
+	CommandAction func(c *UndoCmd) error
+}
+
+func (c *UndoCmd) Usage() {
+	fmt.Fprintf(os.Stderr, "Usage of %s undo:\n", os.Args[0])
+	c.FlagSet.PrintDefaults()
+}
+
+func (c *RootCmd) NewUndo() *UndoCmd {
+	set := flag.NewFlagSet("undo", flag.ExitOnError)
+	v := &UndoCmd{
+		FlagSet: set,
+		parent:  c,
+	}
+	set.Usage = v.Usage
+
+	set.StringVar(&v.runID, "run", "", "Run ID to undo, defaults to the last run")
+
+	set.BoolVar(&v.dryRun, "dry-run", false, "Show what would be restored without moving files")
+
+	v.CommandAction = func(c *UndoCmd) error {
+
+		return Undo(c.runID, c.dryRun)
+	}
+	return v
+}
+
+func (c *UndoCmd) Execute(args []string) error {
+	if err := c.FlagSet.Parse(args); err != nil {
+		return NewUserError(err, fmt.Sprintf("flag parse error %s", err.Error()))
+	}
+	if c.CommandAction != nil {
+		if err := c.CommandAction(c); err != nil {
+			return fmt.Errorf("undo failed: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package mvcommon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConflictPolicy decides what happens when the destination of a move already exists.
+type ConflictPolicy string
+
+const (
+	// ConflictSkip leaves the source where it is.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces the destination with the source.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictRename moves the source to the first free name of the form "name (1).ext", "name (2).ext", ...
+	ConflictRename ConflictPolicy = "rename"
+	// ConflictKeepNewer overwrites the destination only if the source was modified more recently, otherwise skips.
+	ConflictKeepNewer ConflictPolicy = "keep-newer"
+	// ConflictKeepLarger overwrites the destination only if the source is larger, otherwise skips.
+	ConflictKeepLarger ConflictPolicy = "keep-larger"
+	// ConflictFail refuses the whole plan.
+	ConflictFail ConflictPolicy = "fail"
+)
+
+// ConflictPolicies lists every supported ConflictPolicy.
+var ConflictPolicies = []ConflictPolicy{
+	ConflictSkip,
+	ConflictOverwrite,
+	ConflictRename,
+	ConflictKeepNewer,
+	ConflictKeepLarger,
+	ConflictFail,
+}
+
+// ErrDestinationExists is returned when a destination already exists under ConflictFail.
+var ErrDestinationExists = errors.New("destination already exists")
+
+// ParseConflictPolicy parses the name of a ConflictPolicy.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	for _, policy := range ConflictPolicies {
+		if string(policy) == s {
+			return policy, nil
+		}
+	}
+	names := make([]string, len(ConflictPolicies))
+	for i, policy := range ConflictPolicies {
+		names[i] = string(policy)
+	}
+	return "", fmt.Errorf("invalid conflict policy %q, expected one of %s", s, strings.Join(names, ", "))
+}
+
+// ResolveConflicts checks the destination of every move against the file system and against earlier moves of the Plan,
+// and applies policy to those that are already taken. Affected operations record the outcome in Conflict. Under
+// ConflictFail the first conflict is returned as an error wrapping ErrDestinationExists.
+func (p *Plan) ResolveConflicts(policy ConflictPolicy) error {
+	claimed := make(map[string]string)
+	for i := range p.Operations {
+		op := &p.Operations[i]
+		if op.Kind != OperationMove || op.Skip {
+			continue
+		}
+
+		existing, exists := claimed[op.Destination]
+		if !exists {
+			if _, err := os.Lstat(op.Destination); err == nil {
+				existing, exists = op.Destination, true
+			}
+		}
+		if !exists {
+			claimed[op.Destination] = op.Source
+			continue
+		}
+
+		switch policy {
+		case ConflictSkip:
+			op.Skip = true
+			op.Conflict = "destination exists, skipped"
+		case ConflictOverwrite:
+			op.Overwrite = true
+			op.Conflict = "destination exists, overwritten"
+		case ConflictRename:
+			op.Destination = freeName(op.Destination, claimed)
+			op.Conflict = fmt.Sprintf("destination exists, renamed to %s", filepath.Base(op.Destination))
+		case ConflictKeepNewer, ConflictKeepLarger:
+			source, err := os.Stat(op.Source)
+			if err != nil {
+				return fmt.Errorf("failed to inspect %s: %v", op.Source, err)
+			}
+			target, err := os.Stat(existing)
+			if err != nil {
+				return fmt.Errorf("failed to inspect %s: %v", existing, err)
+			}
+			keep, kept := source.ModTime().After(target.ModTime()), "newer"
+			if policy == ConflictKeepLarger {
+				keep, kept = source.Size() > target.Size(), "larger"
+			}
+			if keep {
+				op.Overwrite = true
+				op.Conflict = fmt.Sprintf("destination exists, overwritten by %s source", kept)
+			} else {
+				op.Skip = true
+				op.Conflict = fmt.Sprintf("destination exists, source is not %s, skipped", kept)
+			}
+		case ConflictFail:
+			return fmt.Errorf("%w: %s", ErrDestinationExists, op.Destination)
+		default:
+			return fmt.Errorf("invalid conflict policy %q", policy)
+		}
+
+		if !op.Skip {
+			claimed[op.Destination] = op.Source
+		}
+	}
+	return nil
+}
+
+// freeName returns the first "name (n).ext" variant of path that neither exists nor is claimed.
+func freeName(path string, claimed map[string]string) string {
+	dir, base := filepath.Split(path)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	for n := 1; ; n++ {
+		candidate := filepath.Join(dir, fmt.Sprintf("%s (%d)%s", stem, n, ext))
+		if _, ok := claimed[candidate]; ok {
+			continue
+		}
+		if _, err := os.Lstat(candidate); err == nil {
+			continue
+		}
+		return candidate
+	}
+}
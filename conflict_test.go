@@ -0,0 +1,115 @@
+package mvcommon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResolveConflicts(t *testing.T) {
+	tests := []struct {
+		name            string
+		policy          ConflictPolicy
+		sourceNewer     bool
+		sourceLarger    bool
+		wantErr         error
+		wantSkip        bool
+		wantOverwrite   bool
+		wantDestination string
+	}{
+		{name: "Skip", policy: ConflictSkip, wantSkip: true, wantDestination: "file1.txt"},
+		{name: "Overwrite", policy: ConflictOverwrite, wantOverwrite: true, wantDestination: "file1.txt"},
+		{name: "Rename", policy: ConflictRename, wantDestination: "file1 (2).txt"},
+		{name: "KeepNewer_SourceNewer", policy: ConflictKeepNewer, sourceNewer: true, wantOverwrite: true, wantDestination: "file1.txt"},
+		{name: "KeepNewer_SourceOlder", policy: ConflictKeepNewer, wantSkip: true, wantDestination: "file1.txt"},
+		{name: "KeepLarger_SourceLarger", policy: ConflictKeepLarger, sourceLarger: true, wantOverwrite: true, wantDestination: "file1.txt"},
+		{name: "KeepLarger_SourceSmaller", policy: ConflictKeepLarger, wantSkip: true, wantDestination: "file1.txt"},
+		{name: "Fail", policy: ConflictFail, wantErr: ErrDestinationExists},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			folder := filepath.Join(tempDir, "file")
+			source := filepath.Join(tempDir, "file1.txt")
+			existing := filepath.Join(folder, "file1.txt")
+			if err := os.MkdirAll(folder, 0755); err != nil {
+				t.Fatalf("Failed to create folder: %v", err)
+			}
+
+			sourceData, existingData := "small", "much larger"
+			if test.sourceLarger {
+				sourceData, existingData = existingData, sourceData
+			}
+			for file, data := range map[string]string{
+				source:                                 sourceData,
+				existing:                               existingData,
+				filepath.Join(folder, "file1 (1).txt"): "taken",
+			} {
+				if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+					t.Fatalf("Failed to create temp file: %v", err)
+				}
+			}
+			old, recent := time.Now().Add(-time.Hour), time.Now()
+			if test.sourceNewer {
+				old, recent = recent, old
+			}
+			if err := os.Chtimes(source, old, old); err != nil {
+				t.Fatalf("Failed to set time: %v", err)
+			}
+			if err := os.Chtimes(existing, recent, recent); err != nil {
+				t.Fatalf("Failed to set time: %v", err)
+			}
+
+			plan := PlanMoveToFolder(folder, []string{source})
+			err := plan.ResolveConflicts(test.policy)
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("ResolveConflicts() error = %v, wantErr %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveConflicts() unexpected error: %v", err)
+			}
+
+			op := plan.Moves()[0]
+			if op.Skip != test.wantSkip || op.Overwrite != test.wantOverwrite {
+				t.Errorf("ResolveConflicts() got skip=%v overwrite=%v; want skip=%v overwrite=%v", op.Skip, op.Overwrite, test.wantSkip, test.wantOverwrite)
+			}
+			if want := filepath.Join(folder, test.wantDestination); op.Destination != want {
+				t.Errorf("ResolveConflicts() got destination %q; want %q", op.Destination, want)
+			}
+			if op.Conflict == "" {
+				t.Errorf("ResolveConflicts() did not report the conflict")
+			}
+		})
+	}
+}
+
+func TestResolveConflictsWithinPlan(t *testing.T) {
+	tempDir := t.TempDir()
+	plan := &Plan{}
+	plan.AddFolder(filepath.Join(tempDir, "out"), []string{filepath.Join(tempDir, "a", "x.txt"), filepath.Join(tempDir, "b", "x.txt")}, "")
+
+	if err := plan.ResolveConflicts(ConflictRename); err != nil {
+		t.Fatalf("ResolveConflicts() unexpected error: %v", err)
+	}
+	moves := plan.Moves()
+	if moves[0].Conflict != "" || moves[1].Destination != filepath.Join(tempDir, "out", "x (1).txt") {
+		t.Errorf("ResolveConflicts() got %#v", moves)
+	}
+}
+
+func TestParseConflictPolicy(t *testing.T) {
+	for _, policy := range ConflictPolicies {
+		if got, err := ParseConflictPolicy(string(policy)); err != nil || got != policy {
+			t.Errorf("ParseConflictPolicy(%q) = %q, %v", policy, got, err)
+		}
+	}
+	if _, err := ParseConflictPolicy("clobber"); err == nil {
+		t.Errorf("ParseConflictPolicy(%q) succeeded", "clobber")
+	}
+}
@@ -1,7 +1,9 @@
 package mvcommon
 
 const (
-	DefaultTrim = "-_ ."
+	DefaultTrim           = "-_ ."
+	DefaultConflictPolicy = ConflictFail
+	DefaultFolderTemplate = "{{.Prefix}}"
 )
 
 var (
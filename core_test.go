@@ -1,9 +1,12 @@
 package mvcommon
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestCommonPrefixSplit(t *testing.T) {
@@ -78,6 +81,50 @@ func TestCommonPrefixSplit(t *testing.T) {
 			minimumLength: 3,
 			expected:      "common_prefix",
 		},
+		{
+			name:          "CommonPrefix_Japanese",
+			names:         []string{"報告書 - 第1版.txt", "報告書 - 第2版.txt"},
+			stopWords:     []string{" - ", "] ", "["},
+			trim:          "_- ",
+			minimumLength: 2,
+			expected:      "報告書",
+		},
+		{
+			name:          "CommonPrefix_Cyrillic",
+			names:         []string{"Отчёт_2024_январь.pdf", "Отчёт_2024_февраль.pdf"},
+			stopWords:     []string{" - ", "] ", "["},
+			trim:          "_- ",
+			minimumLength: 3,
+			expected:      "Отчёт_2024",
+		},
+		{
+			name:          "CommonPrefix_AccentedCharactersSharingLeadingByte",
+			names:         []string{"Résumé A.txt", "Résumè B.txt"},
+			trim:          "_- ",
+			minimumLength: 3,
+			expected:      "Résum",
+		},
+		{
+			name:          "CommonPrefix_CombiningMarkNotSplit",
+			names:         []string{"Cafe\u0301 menu.txt", "Cafe menu.txt"},
+			trim:          "_- ",
+			minimumLength: 3,
+			expected:      "Caf",
+		},
+		{
+			name:          "CommonPrefix_EmojiZWJSequenceNotSplit",
+			names:         []string{"🎵 Song 👨‍👩‍👧 Part 1.mp3", "🎵 Song 👨‍👩‍👦 Part 2.mp3"},
+			trim:          "_- ",
+			minimumLength: 3,
+			expected:      "🎵 Song",
+		},
+		{
+			name:          "CommonPrefix_FlagsNotSplit",
+			names:         []string{"🇯🇵 trip 1.jpg", "🇯🇲 trip 2.jpg"},
+			trim:          "_- ",
+			minimumLength: 3,
+			expected:      "trip",
+		},
 		{
 			name:          "CommonPrefix_EmptyNames_ReturnsEmptyString",
 			names:         []string{},
@@ -91,6 +138,9 @@ func TestCommonPrefixSplit(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			result := CommonPrefixSplit(test.names, test.stopWords, test.trim, test.minimumLength)
+			if !utf8.ValidString(result) {
+				t.Errorf("CommonPrefixSplit(%v, %#v, %#v, %d) got invalid UTF-8 %q", test.names, test.stopWords, test.trim, test.minimumLength, result)
+			}
 			if result != test.expected {
 				t.Errorf("CommonPrefixSplit(%v, %#v, %#v, %d) got %q; want %q", test.names, test.stopWords, test.trim, test.minimumLength, result, test.expected)
 			}
@@ -148,3 +198,27 @@ func TestMoveFilesActual(t *testing.T) {
 		}
 	}
 }
+
+// benchmarkNames returns n names of a series with a stray word and a varying tail, like a large scanned directory.
+func benchmarkNames(n int) []string {
+	names := make([]string, n)
+	tags := []string{"Draft", "Final", "Review", "Notes"}
+	for i := range names {
+		names[i] = fmt.Sprintf("Project Apollo - %s %05d - rev%d.txt", tags[i%len(tags)], i, i%7)
+	}
+	return names
+}
+
+func BenchmarkCommonPrefixSplit(b *testing.B) {
+	for _, n := range []int{10, 1000, 50000} {
+		names := benchmarkNames(n)
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if prefix := CommonPrefixSplit(names, DefaultStopWords, DefaultTrim, 3); prefix != "Project Apollo" {
+					b.Fatalf("CommonPrefixSplit() got %q; want %q", prefix, "Project Apollo")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,430 @@
+package mvcommon
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// Options configures a Detector.
+type Options struct {
+	// StopWords end a match, a prefix never runs across one.
+	StopWords []string
+	// Trim holds the characters removed from both ends of a match.
+	Trim string
+	// MinLength is the shortest match considered, in characters.
+	MinLength int
+	// FoldCase compares names case-insensitively.
+	FoldCase bool
+	// Normalization is the Unicode normalization form names are compared in, empty compares them as they are.
+	Normalization Normalization
+	// Tokenizer splits names into the units matches are made of. Nil splits them into characters and picks the earliest
+	// shared match, otherwise the longest shared run of units wins, ties going to the earliest.
+	Tokenizer Tokenizer
+	// KeepExtension matches file extensions like any other text, by default they are left out so ".txt" never ends up
+	// in a prefix. See Ext.
+	KeepExtension bool
+	// Anchor restricts where in the names a match may be, empty is AnchorAny. Anchored matches prefer the longest.
+	Anchor Anchor
+	// MinShare is the fraction of names a match must be found in, the others are returned in Result.Unmatched. A match
+	// is always found in at least two names. Zero, or any value outside (0, 1], requires every name.
+	MinShare float64
+	// Scorer picks between the matches found in the same number of names, nil prefers the earliest, or the longest with
+	// a Tokenizer or an Anchor. See Strategy for the built-in ones.
+	Scorer Scorer
+}
+
+// DefaultOptions returns the Options a Detector starts from.
+func DefaultOptions() Options {
+	return Options{
+		StopWords: DefaultStopWords,
+		Trim:      DefaultTrim,
+	}
+}
+
+// Option changes one setting of the Options a Detector is created with.
+type Option func(*Options)
+
+// WithOptions replaces all settings with opts.
+func WithOptions(opts Options) Option {
+	return func(o *Options) {
+		*o = opts
+	}
+}
+
+// WithStopWords sets the stop words, none disables them.
+func WithStopWords(stopWords ...string) Option {
+	return func(o *Options) {
+		o.StopWords = stopWords
+	}
+}
+
+// WithTrim sets the characters trimmed from both ends of a match.
+func WithTrim(trim string) Option {
+	return func(o *Options) {
+		o.Trim = trim
+	}
+}
+
+// WithMinLength sets the shortest match considered.
+func WithMinLength(minLength int) Option {
+	return func(o *Options) {
+		o.MinLength = minLength
+	}
+}
+
+// WithFoldCase sets whether names are compared case-insensitively.
+func WithFoldCase(foldCase bool) Option {
+	return func(o *Options) {
+		o.FoldCase = foldCase
+	}
+}
+
+// WithNormalization sets the Unicode normalization form names are compared in.
+func WithNormalization(normalization Normalization) Option {
+	return func(o *Options) {
+		o.Normalization = normalization
+	}
+}
+
+// WithTokenizer sets the Tokenizer names are split with, nil matches single characters.
+func WithTokenizer(tokenizer Tokenizer) Option {
+	return func(o *Options) {
+		o.Tokenizer = tokenizer
+	}
+}
+
+// WithKeepExtension sets whether file extensions take part in matching.
+func WithKeepExtension(keepExtension bool) Option {
+	return func(o *Options) {
+		o.KeepExtension = keepExtension
+	}
+}
+
+// WithAnchor sets where in the names a match may be.
+func WithAnchor(anchor Anchor) Option {
+	return func(o *Options) {
+		o.Anchor = anchor
+	}
+}
+
+// WithMinShare sets the fraction of names a match must be found in.
+func WithMinShare(minShare float64) Option {
+	return func(o *Options) {
+		o.MinShare = minShare
+	}
+}
+
+// WithScorer sets the Scorer matches are picked by.
+func WithScorer(scorer Scorer) Option {
+	return func(o *Options) {
+		o.Scorer = scorer
+	}
+}
+
+// Match is where the detected prefix was found in one name.
+type Match struct {
+	Name string
+	// Offset and Length locate the prefix in Name, in bytes. They always fall on character boundaries.
+	Offset int
+	Length int
+}
+
+// Result is the outcome of a detection.
+type Result struct {
+	// Prefix is the detected common prefix, with trim characters removed. When names spell it differently, as they may
+	// when normalizing or folding case, it is the spelling most names use.
+	Prefix string
+	// Key is Prefix in the form names were compared in, names sharing a prefix share its Key.
+	Key string
+	// Untrimmed is the text shared by every name at the match before trim characters were removed.
+	Untrimmed string
+	// Score rates the match, higher is better. It is the score the Scorer gave it, without one it is 1/(1+p) where p is
+	// the average position of the match in characters.
+	Score float64
+	// Matches holds the match in each name it was found in, in the order the names were given.
+	Matches []Match
+	// Unmatched holds the names the match was not found in, in the order they were given. It is only ever set when
+	// MinShare lets a match leave names out.
+	Unmatched []string
+	// StopWords are the stop words found next to the match, which kept it from growing further.
+	StopWords []string
+	// TrimChars are the trim characters removed from Untrimmed to give Prefix.
+	TrimChars string
+}
+
+// Detector detects the common prefix of a set of names.
+type Detector struct {
+	opts Options
+}
+
+// NewDetector returns a Detector using DefaultOptions changed by opts, applied in order.
+func NewDetector(opts ...Option) *Detector {
+	d := &Detector{opts: DefaultOptions()}
+	for _, opt := range opts {
+		opt(&d.opts)
+	}
+	return d
+}
+
+// Options returns the settings of the Detector.
+func (d *Detector) Options() Options {
+	return d.opts
+}
+
+// Detect finds the common prefix of names. It returns ErrTooFewFiles if names is empty and ErrNoCommonPrefix if no
+// match satisfies the options.
+func (d *Detector) Detect(names []string) (Result, error) {
+	if len(names) == 0 {
+		return Result{}, ErrTooFewFiles
+	}
+	s := d.search(names)
+	best := s.best()
+	if best == nil {
+		return Result{}, ErrNoCommonPrefix
+	}
+	return d.result(names, s, best)
+}
+
+// DetectCandidates returns up to n alternative matches of names, ranked the way Detect picks between them, so the
+// first is the one Detect returns. A match found within a better ranked one, such as "port" after "Report 234", is
+// left out. n of 0 or less returns all of them. It returns the same errors as Detect.
+func (d *Detector) DetectCandidates(names []string, n int) ([]Result, error) {
+	if len(names) == 0 {
+		return nil, ErrTooFewFiles
+	}
+	s := d.search(names)
+	var results []Result
+	for _, c := range s.candidates() {
+		result, err := d.result(names, s, s.summary(c))
+		if err != nil {
+			continue
+		}
+		if slices.ContainsFunc(results, func(r Result) bool { return strings.Contains(r.Key, result.Key) }) {
+			continue
+		}
+		results = append(results, result)
+		if len(results) == n {
+			break
+		}
+	}
+	if len(results) == 0 {
+		return nil, ErrNoCommonPrefix
+	}
+	return results, nil
+}
+
+// search prepares the search for matches shared by names.
+func (d *Detector) search(names []string) *search {
+
+	// Names are compared by their keys, which differ from the names themselves when normalizing or folding case.
+	segments := make([]segmented, len(names))
+	for ni, name := range names {
+		segments[ni] = d.opts.segment(name)
+	}
+	stopWords := make([]string, len(d.opts.StopWords))
+	for i, stopWord := range d.opts.StopWords {
+		stopWords[i] = d.opts.compareForm(stopWord)
+	}
+	trimSet := d.opts.trimSet()
+	anchor := cmp.Or(d.opts.Anchor, AnchorAny)
+	preferLonger := d.opts.Tokenizer != nil || anchor != AnchorAny
+
+	// Matches are looked for in units [0, ends[ni]) of name ni, which leaves out the extension unless KeepExtension is
+	// set. A match may only take in unit ends[ni]-1 when reach[ni] is set, so it never covers a whole name. With
+	// AnchorSuffix a match must end at ends[ni], which then also leaves out the trim characters before the extension.
+	ends := make([]int, len(names))
+	reach := make([]bool, len(names))
+	for ni, seg := range segments {
+		ends[ni] = seg.len()
+		if !d.opts.KeepExtension || anchor == AnchorSuffix {
+			if ext := Ext(seg.name); ext != "" {
+				ends[ni] = seg.unitAt(len(seg.name) - len(ext))
+				reach[ni] = true
+			}
+		}
+		if anchor == AnchorSuffix {
+			for ends[ni] > 0 && trimSet.has(seg.unit(ends[ni]-1)) {
+				ends[ni]--
+			}
+			reach[ni] = true
+		}
+	}
+
+	// Matches are made of whole units, grapheme clusters unless a Tokenizer is set, so a prefix never splits a character.
+	return &search{
+		opts:         d.opts,
+		segments:     segments,
+		stopWords:    stopWords,
+		trimSet:      trimSet,
+		ends:         ends,
+		reach:        reach,
+		anchor:       anchor,
+		preferLonger: preferLonger,
+		required:     d.opts.required(len(names)),
+	}
+}
+
+// result describes the match best found by s in names.
+func (d *Detector) result(names []string, s *search, best *matchSummary) (Result, error) {
+	segments, trimSet, stopWords := s.segments, s.trimSet, s.stopWords
+
+	// Only the names the match was found in take part from here on.
+	segments = slices.Clone(segments)
+	matched := make([]string, len(best.Names))
+	for i, ni := range best.Names {
+		segments[i] = segments[ni]
+		matched[i] = names[ni]
+	}
+	segments = segments[:len(best.Names)]
+
+	// Trim spaces and clean up the prefix
+	first := best.Matches[0]
+	lo, hi := 0, first.len
+	for lo < hi && trimSet.has(segments[0].unit(first.pos+lo)) {
+		lo++
+	}
+	for hi > lo && trimSet.has(segments[0].unit(first.pos+hi-1)) {
+		hi--
+	}
+	if lo == hi {
+		return Result{}, ErrNoCommonPrefix
+	}
+
+	// The trim characters every name shares after the match make up the untrimmed match.
+	extra := 0
+	for {
+		var shared string
+		for i, m := range best.Matches {
+			end := m.pos + hi + extra
+			if end >= segments[i].len() || !trimSet.has(segments[i].unit(end)) || (i > 0 && segments[i].unit(end) != shared) {
+				shared = ""
+				break
+			}
+			shared = segments[i].unit(end)
+		}
+		if shared == "" {
+			break
+		}
+		extra++
+	}
+
+	result := Result{
+		Key:   segments[0].keyOf(first.pos+lo, first.pos+hi),
+		Score: 1 / (1 + float64(best.AveragePos)/100),
+	}
+	if d.opts.Scorer != nil {
+		result.Score = best.Score
+	}
+	untrimmedEnds := make([]int, len(matched))
+	for i, m := range best.Matches {
+		seg := segments[i]
+		offset := seg.bounds[m.pos+lo]
+		result.Matches = append(result.Matches, Match{Name: matched[i], Offset: offset, Length: seg.bounds[m.pos+hi] - offset})
+		untrimmedEnds[i] = m.pos + hi + extra
+	}
+	display := commonestSpelling(result.Matches)
+	dm := result.Matches[display]
+	result.Prefix = dm.Name[dm.Offset : dm.Offset+dm.Length]
+	result.Untrimmed = dm.Name[dm.Offset:segments[display].bounds[untrimmedEnds[display]]]
+	result.TrimChars = trimmedChars(result.Untrimmed, result.Prefix)
+	for i, stopWord := range stopWords {
+		if stopWord == "" {
+			continue
+		}
+		for mi, m := range best.Matches {
+			if adjacentStopWord(segments[mi], m.pos+lo, m.pos+hi, untrimmedEnds[mi], stopWord) {
+				result.StopWords = append(result.StopWords, d.opts.StopWords[i])
+				break
+			}
+		}
+	}
+	for ni, name := range names {
+		if _, found := slices.BinarySearch(best.Names, ni); !found {
+			result.Unmatched = append(result.Unmatched, name)
+		}
+	}
+	return result, nil
+}
+
+// required returns how many of n names a match must be found in.
+func (o Options) required(n int) int {
+	if o.MinShare <= 0 || o.MinShare >= 1 {
+		return n
+	}
+	return max(int(math.Ceil(o.MinShare*float64(n))), min(n, 2))
+}
+
+// graphemeBounds returns the byte offsets at which the grapheme clusters of s start, followed by len(s).
+func graphemeBounds(s string) []int {
+	bounds := []int{0}
+	state := -1
+	for rest := s; len(rest) > 0; {
+		var cluster string
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		bounds = append(bounds, bounds[len(bounds)-1]+len(cluster))
+	}
+	return bounds
+}
+
+// unitSet is a set of grapheme clusters, such as the trim characters.
+type unitSet map[string]struct{}
+
+func (s unitSet) has(unit string) bool {
+	_, ok := s[unit]
+	return ok
+}
+
+// trimSet returns the trim characters in the form names are compared in.
+func (o Options) trimSet() unitSet {
+	set := make(unitSet)
+	o.Tokenizer = nil
+	seg := o.segment(o.Trim)
+	for i := 0; i < seg.len(); i++ {
+		set[seg.unit(i)] = struct{}{}
+	}
+	return set
+}
+
+// commonestSpelling returns the index of the match spelled like most others, the earliest one on a tie. Names only
+// spell a match differently when normalizing or folding case.
+func commonestSpelling(matches []Match) int {
+	counts := make(map[string]int)
+	best := 0
+	for i, m := range matches {
+		spelling := m.Name[m.Offset : m.Offset+m.Length]
+		counts[spelling]++
+		if counts[spelling] > counts[matches[best].Name[matches[best].Offset:matches[best].Offset+matches[best].Length]] {
+			best = i
+		}
+	}
+	return best
+}
+
+// trimmedChars returns the distinct characters of untrimmed that were trimmed away to leave prefix.
+func trimmedChars(untrimmed, prefix string) string {
+	var sb strings.Builder
+	for _, c := range untrimmed[len(prefix):] {
+		if !strings.ContainsRune(sb.String(), c) {
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
+
+// adjacentStopWord reports whether stopWord starts between the last unit of the match [from, to) and the end of the
+// untrimmed match, or ends right where the match starts. Such a stop word cut the match short.
+func adjacentStopWord(seg segmented, from, to, untrimmedEnd int, stopWord string) bool {
+	if strings.HasSuffix(seg.key[:seg.keyBounds[from]], stopWord) {
+		return true
+	}
+	for i := seg.keyBounds[to-1]; i < seg.keyBounds[untrimmedEnd]; i++ {
+		if strings.HasPrefix(seg.key[i:], stopWord) {
+			return true
+		}
+	}
+	return false
+}
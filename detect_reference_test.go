@@ -0,0 +1,326 @@
+package mvcommon
+
+import (
+	"cmp"
+	"errors"
+	"maps"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rivo/uniseg"
+)
+
+// referenceDetect is the original detector, which grows every shared substring one unit at a time. It is slow but
+// simple, and Detect must always agree with it.
+func referenceDetect(d *Detector, names []string) (Result, error) {
+	if len(names) == 0 {
+		return Result{}, ErrTooFewFiles
+	}
+	minMatch := d.opts.MinLength
+	required := d.opts.required(len(names))
+
+	// Matches are made of whole units, grapheme clusters unless a Tokenizer is set, so a prefix never splits a character.
+	// pos and len count units.
+	type occurrence struct {
+		pos int
+		len int
+	}
+
+	// Names lists the names the match was found in, Matches holds the match in each of them.
+	type MatchSummary struct {
+		Names      []int
+		Matches    []occurrence
+		AveragePos int
+		Length     int
+	}
+
+	// Names are compared by their keys, which differ from the names themselves when normalizing or folding case.
+	segments := make([]segmented, len(names))
+	for ni, name := range names {
+		segments[ni] = d.opts.segment(name)
+	}
+	stopWords := make([]string, len(d.opts.StopWords))
+	for i, stopWord := range d.opts.StopWords {
+		stopWords[i] = d.opts.compareForm(stopWord)
+	}
+	trimSet := d.opts.trimSet()
+	anchor := cmp.Or(d.opts.Anchor, AnchorAny)
+	preferLonger := d.opts.Tokenizer != nil || anchor != AnchorAny
+
+	// Matches are looked for in units [0, ends[ni]) of name ni, which leaves out the extension unless KeepExtension is
+	// set. A match may only take in unit ends[ni]-1 when reach[ni] is set, so it never covers a whole name. With
+	// AnchorSuffix a match must end at ends[ni], which then also leaves out the trim characters before the extension.
+	ends := make([]int, len(names))
+	reach := make([]bool, len(names))
+	for ni, seg := range segments {
+		ends[ni] = seg.len()
+		if !d.opts.KeepExtension || anchor == AnchorSuffix {
+			if ext := Ext(seg.name); ext != "" {
+				ends[ni] = seg.unitAt(len(seg.name) - len(ext))
+				reach[ni] = true
+			}
+		}
+		if anchor == AnchorSuffix {
+			for ends[ni] > 0 && trimSet.has(seg.unit(ends[ni]-1)) {
+				ends[ni]--
+			}
+			reach[ni] = true
+		}
+	}
+	// pick returns the occurrence in name ni the match is taken from, false if none satisfies the anchor.
+	pick := func(ni int, occurrences []occurrence) (occurrence, bool) {
+		if anchor != AnchorSuffix {
+			return occurrences[0], true
+		}
+		for _, o := range occurrences {
+			if o.pos+o.len == ends[ni] {
+				return o, true
+			}
+		}
+		return occurrence{}, false
+	}
+
+	matchLookup := make(map[string]map[int][]occurrence)
+
+	var best *MatchSummary
+
+	for ni, seg := range segments {
+		name, b := seg.key, seg.keyBounds
+		for i := 0; i < ends[ni]; i++ {
+			me := occurrence{pos: i, len: 1}
+			substr := seg.unit(i)
+			if trimSet.has(substr) {
+				continue
+			}
+			skip := 0
+			for _, stopWord := range stopWords {
+				if len(name) > b[i]+len(stopWord) && strings.HasPrefix(name[b[i]:], stopWord) {
+					skip = max(len(stopWord), skip)
+				}
+			}
+			if skip > 0 {
+				for end := b[i] + skip; b[i+1] < end; {
+					i++
+				}
+				continue
+			}
+			if _, ok := matchLookup[substr]; !ok {
+				matchLookup[substr] = make(map[int][]occurrence)
+			}
+			matchLookup[substr][ni] = append(matchLookup[substr][ni], me)
+			if anchor == AnchorPrefix {
+				break
+			}
+		}
+	}
+
+	for len(matchLookup) > 0 {
+		nextLookup := make(map[string]map[int][]occurrence)
+		// Candidates are visited in a fixed order so ties are always settled the same way.
+		for _, matchStr := range slices.Sorted(maps.Keys(matchLookup)) {
+			prefixMap := matchLookup[matchStr]
+			if len(prefixMap) < required {
+				continue
+			}
+			sampleI := slices.Min(slices.Collect(maps.Keys(prefixMap)))
+			sample := prefixMap[sampleI][0]
+			trimChar := trimSet.has(segments[sampleI].unit(sample.pos + sample.len - 1))
+			length := sample.len
+			if d.opts.Tokenizer != nil {
+				seg := segments[sampleI]
+				length = uniseg.GraphemeClusterCount(seg.name[seg.bounds[sample.pos]:seg.bounds[sample.pos+sample.len]])
+			}
+			if length >= minMatch && !trimChar {
+				sum := 0
+				var found []int
+				var firstMatch []occurrence
+				for nameI := range names {
+					if len(prefixMap[nameI]) == 0 {
+						continue
+					}
+					m, ok := pick(nameI, prefixMap[nameI])
+					if !ok {
+						continue
+					}
+					found = append(found, nameI)
+					firstMatch = append(firstMatch, m)
+					sum += m.pos
+				}
+				eligible := len(found) >= required
+				averagePos := 0
+				if eligible {
+					averagePos = (sum * 100) / len(found)
+				}
+				// A match found in more names always wins, so names are only left out when they have to be.
+				wider := best != nil && len(found) > len(best.Names)
+				narrower := best != nil && len(found) < len(best.Names)
+				longer := preferLonger && best != nil && sample.len > best.Length
+				if eligible && !narrower && (best == nil || wider || longer || best.AveragePos >= averagePos) {
+					best = &MatchSummary{
+						Names:      found,
+						Matches:    firstMatch,
+						AveragePos: averagePos,
+						Length:     sample.len,
+					}
+				}
+			}
+			for nameI, nameMatchesForPrefix := range prefixMap {
+				seg := segments[nameI]
+				name, b := seg.key, seg.keyBounds
+				for i := range nameMatchesForPrefix {
+					matchesForPrefix := nameMatchesForPrefix[i]
+					last := b[matchesForPrefix.pos+matchesForPrefix.len-1]
+					skip := 0
+					for _, stopWord := range stopWords {
+						if len(name) > last+len(stopWord) && strings.HasPrefix(name[last:], stopWord) {
+							skip = max(len(stopWord), skip)
+						}
+					}
+					if skip > 0 {
+						continue
+					}
+					matchesForPrefix.len++
+					if end := matchesForPrefix.pos + matchesForPrefix.len; end > ends[nameI] || (end == ends[nameI] && !reach[nameI]) {
+						continue
+					}
+					substr := seg.keyOf(matchesForPrefix.pos, matchesForPrefix.pos+matchesForPrefix.len)
+					_, ok := nextLookup[substr]
+					if !ok {
+						nextLookup[substr] = map[int][]occurrence{nameI: {matchesForPrefix}}
+						continue
+					}
+					nextLookup[substr][nameI] = append(nextLookup[substr][nameI], matchesForPrefix)
+				}
+			}
+		}
+
+		if len(nextLookup) == 0 {
+			break
+		}
+		matchLookup = nextLookup
+	}
+
+	if best == nil {
+		return Result{}, ErrNoCommonPrefix
+	}
+
+	// Only the names the match was found in take part from here on.
+	segments = slices.Clone(segments)
+	matched := make([]string, len(best.Names))
+	for i, ni := range best.Names {
+		segments[i] = segments[ni]
+		matched[i] = names[ni]
+	}
+	segments = segments[:len(best.Names)]
+
+	// Trim spaces and clean up the prefix
+	first := best.Matches[0]
+	lo, hi := 0, first.len
+	for lo < hi && trimSet.has(segments[0].unit(first.pos+lo)) {
+		lo++
+	}
+	for hi > lo && trimSet.has(segments[0].unit(first.pos+hi-1)) {
+		hi--
+	}
+	if lo == hi {
+		return Result{}, ErrNoCommonPrefix
+	}
+
+	// The trim characters every name shares after the match make up the untrimmed match.
+	extra := 0
+	for {
+		var shared string
+		for i, m := range best.Matches {
+			end := m.pos + hi + extra
+			if end >= segments[i].len() || !trimSet.has(segments[i].unit(end)) || (i > 0 && segments[i].unit(end) != shared) {
+				shared = ""
+				break
+			}
+			shared = segments[i].unit(end)
+		}
+		if shared == "" {
+			break
+		}
+		extra++
+	}
+
+	result := Result{
+		Key:   segments[0].keyOf(first.pos+lo, first.pos+hi),
+		Score: 1 / (1 + float64(best.AveragePos)/100),
+	}
+	untrimmedEnds := make([]int, len(matched))
+	for i, m := range best.Matches {
+		seg := segments[i]
+		offset := seg.bounds[m.pos+lo]
+		result.Matches = append(result.Matches, Match{Name: matched[i], Offset: offset, Length: seg.bounds[m.pos+hi] - offset})
+		untrimmedEnds[i] = m.pos + hi + extra
+	}
+	display := commonestSpelling(result.Matches)
+	dm := result.Matches[display]
+	result.Prefix = dm.Name[dm.Offset : dm.Offset+dm.Length]
+	result.Untrimmed = dm.Name[dm.Offset:segments[display].bounds[untrimmedEnds[display]]]
+	result.TrimChars = trimmedChars(result.Untrimmed, result.Prefix)
+	for i, stopWord := range stopWords {
+		if stopWord == "" {
+			continue
+		}
+		for mi, m := range best.Matches {
+			if adjacentStopWord(segments[mi], m.pos+lo, m.pos+hi, untrimmedEnds[mi], stopWord) {
+				result.StopWords = append(result.StopWords, d.opts.StopWords[i])
+				break
+			}
+		}
+	}
+	for ni, name := range names {
+		if _, found := slices.BinarySearch(best.Names, ni); !found {
+			result.Unmatched = append(result.Unmatched, name)
+		}
+	}
+	return result, nil
+}
+
+func TestDetectorDetectMatchesReference(t *testing.T) {
+	// Names are built from a few pieces, so they share plenty of text, stop words, trim characters and extensions.
+	pieces := []string{"Re", "port", " ", "-", " - ", "_", "2024", "ab", "Ab", "é", "é", "報告", "[", "] ", ".txt", ".tar.gz", "x"}
+	configs := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "Defaults"},
+		{name: "Min3", opts: []Option{WithTrim("_- "), WithMinLength(3)}},
+		{name: "NoStopWords", opts: []Option{WithStopWords(), WithTrim("")}},
+		{name: "Tokens", opts: []Option{WithTokenizer(Words), WithMinLength(2)}},
+		{name: "FoldCase", opts: []Option{WithFoldCase(true), WithNormalization(NormalizeNFC)}},
+		{name: "Prefix", opts: []Option{WithAnchor(AnchorPrefix)}},
+		{name: "Suffix", opts: []Option{WithAnchor(AnchorSuffix), WithTrim("_- ")}},
+		{name: "SuffixTokens", opts: []Option{WithAnchor(AnchorSuffix), WithTokenizer(Words)}},
+		{name: "KeepExtension", opts: []Option{WithKeepExtension(true)}},
+		{name: "MinShare", opts: []Option{WithMinShare(0.6), WithMinLength(2)}},
+		{name: "MinShareTokens", opts: []Option{WithMinShare(0.5), WithTokenizer(Words), WithFoldCase(true)}},
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, config := range configs {
+		t.Run(config.name, func(t *testing.T) {
+			detector := NewDetector(config.opts...)
+			for range 400 {
+				names := make([]string, 1+rng.IntN(5))
+				for i := range names {
+					var sb strings.Builder
+					for range 1 + rng.IntN(6) {
+						sb.WriteString(pieces[rng.IntN(len(pieces))])
+					}
+					names[i] = sb.String()
+				}
+				got, gotErr := detector.Detect(names)
+				expected, expectedErr := referenceDetect(detector, names)
+				if !errors.Is(gotErr, expectedErr) || !reflect.DeepEqual(got, expected) {
+					t.Fatalf("Detect(%q) got (%#v, %v); want (%#v, %v)", names, got, gotErr, expected, expectedErr)
+				}
+			}
+		})
+	}
+}
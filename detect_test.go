@@ -0,0 +1,402 @@
+package mvcommon
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNewDetectorOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected Options
+	}{
+		{
+			name:     "Defaults",
+			expected: DefaultOptions(),
+		},
+		{
+			name: "Overrides",
+			opts: []Option{WithStopWords(" - "), WithTrim("_"), WithMinLength(3)},
+			expected: Options{
+				StopWords: []string{" - "},
+				Trim:      "_",
+				MinLength: 3,
+			},
+		},
+		{
+			name:     "AppliedInOrder",
+			opts:     []Option{WithMinLength(3), WithOptions(Options{Trim: " "}), WithMinLength(5)},
+			expected: Options{Trim: " ", MinLength: 5},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := NewDetector(test.opts...).Options(); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("NewDetector().Options() got %#v; want %#v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestDetectorDetect(t *testing.T) {
+	detector := NewDetector(WithTrim("_- "), WithMinLength(3))
+	tests := []struct {
+		name     string
+		names    []string
+		expected Result
+		err      error
+	}{
+		{
+			name:  "Found",
+			names: []string{"Report 234 - Draft1.txt", "Report 234 - Final.txt"},
+			expected: Result{
+				Prefix:    "Report 234",
+				Key:       "Report 234",
+				Untrimmed: "Report 234 - ",
+				Score:     1,
+				Matches: []Match{
+					{Name: "Report 234 - Draft1.txt", Offset: 0, Length: 10},
+					{Name: "Report 234 - Final.txt", Offset: 0, Length: 10},
+				},
+				StopWords: []string{" - "},
+				TrimChars: " -",
+			},
+		},
+		{
+			name:  "AfterStopWord",
+			names: []string{"[Draft] Report - v1.txt", "[Final] Report - v2.txt"},
+			expected: Result{
+				Prefix:    "Report",
+				Key:       "Report",
+				Untrimmed: "Report - ",
+				Score:     1.0 / 9,
+				Matches: []Match{
+					{Name: "[Draft] Report - v1.txt", Offset: 8, Length: 6},
+					{Name: "[Final] Report - v2.txt", Offset: 8, Length: 6},
+				},
+				StopWords: []string{" - ", "] "},
+				TrimChars: " -",
+			},
+		},
+		{
+			name:  "OffsetsInBytes",
+			names: []string{"第1版 報告書 A", "最終 報告書 B"},
+			expected: Result{
+				Prefix:    "報告書",
+				Key:       "報告書",
+				Untrimmed: "報告書 ",
+				Score:     1.0 / 4.5,
+				Matches: []Match{
+					{Name: "第1版 報告書 A", Offset: 8, Length: 9},
+					{Name: "最終 報告書 B", Offset: 7, Length: 9},
+				},
+				TrimChars: " ",
+			},
+		},
+		{
+			name:  "Empty",
+			names: nil,
+			err:   ErrTooFewFiles,
+		},
+		{
+			name:  "NoCommonPrefix",
+			names: []string{"alpha", "xyz"},
+			err:   ErrNoCommonPrefix,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := detector.Detect(test.names)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Detect(%q) got error %v; want %v", test.names, err, test.err)
+			}
+			if !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("Detect(%q) got %#v; want %#v", test.names, result, test.expected)
+			}
+		})
+	}
+}
+
+func TestDetectorDetectNormalized(t *testing.T) {
+	tests := []struct {
+		name     string
+		names    []string
+		opts     []Option
+		expected string
+		key      string
+	}{
+		{
+			name:     "CaseSensitiveByDefault",
+			names:    []string{"REPORT_2024 scan.pdf", "Report_2024 notes.pdf", "Report_2024 final.pdf"},
+			expected: "2024",
+			key:      "2024",
+		},
+		{
+			name:     "IgnoreCase_CommonestSpelling",
+			names:    []string{"REPORT_2024 scan.pdf", "Report_2024 notes.pdf", "Report_2024 final.pdf"},
+			opts:     []Option{WithFoldCase(true)},
+			expected: "Report_2024",
+			key:      "report_2024",
+		},
+		{
+			name:     "IgnoreCase_TieKeepsFirst",
+			names:    []string{"REPORT_2024 scan.pdf", "Report_2024 notes.pdf"},
+			opts:     []Option{WithFoldCase(true)},
+			expected: "REPORT_2024",
+			key:      "report_2024",
+		},
+		{
+			name:     "NFC_MatchesDecomposed",
+			names:    []string{"Cafe\u0301 menu 1.txt", "Caf\u00e9 menu 2.txt", "Caf\u00e9 menu 3.txt"},
+			opts:     []Option{WithNormalization(NormalizeNFC)},
+			expected: "Caf\u00e9 menu",
+			key:      "Caf\u00e9 menu",
+		},
+		{
+			name:     "NFD_MatchesComposed",
+			names:    []string{"Cafe\u0301 menu 1.txt", "Caf\u00e9 menu 2.txt"},
+			opts:     []Option{WithNormalization(NormalizeNFD)},
+			expected: "Cafe\u0301 menu",
+			key:      "Cafe\u0301 menu",
+		},
+		{
+			name:     "NFKC_MatchesFullWidth",
+			names:    []string{"ＡＢＣ report 1.txt", "ABC report 2.txt"},
+			opts:     []Option{WithNormalization(NormalizeNFKC)},
+			expected: "ＡＢＣ report",
+			key:      "ABC report",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := append([]Option{WithTrim("_- "), WithMinLength(3)}, test.opts...)
+			result, err := NewDetector(opts...).Detect(test.names)
+			if err != nil {
+				t.Fatalf("Detect(%q) failed: %v", test.names, err)
+			}
+			if result.Prefix != test.expected || result.Key != test.key {
+				t.Errorf("Detect(%q) got prefix %q key %q; want prefix %q key %q", test.names, result.Prefix, result.Key, test.expected, test.key)
+			}
+		})
+	}
+}
+
+func TestParseNormalization(t *testing.T) {
+	for _, n := range Normalizations {
+		if got, err := ParseNormalization(string(n)); err != nil || got != n {
+			t.Errorf("ParseNormalization(%q) got %q, %v", n, got, err)
+		}
+	}
+	if got, err := ParseNormalization(""); err != nil || got != NormalizeNone {
+		t.Errorf("ParseNormalization(\"\") got %q, %v; want %q", got, err, NormalizeNone)
+	}
+	if _, err := ParseNormalization("nfkd"); err == nil {
+		t.Errorf("ParseNormalization(\"nfkd\") succeeded")
+	}
+}
+
+func TestDetectorDetectAnchor(t *testing.T) {
+	tests := []struct {
+		name     string
+		names    []string
+		anchor   Anchor
+		expected string
+		err      error
+	}{
+		{
+			name:     "Any_Infix",
+			names:    []string{"Draft notes 2024 a", "Final notes 2024 b"},
+			anchor:   AnchorAny,
+			expected: "notes 2024",
+		},
+		{
+			name:   "Prefix_NoSharedStart",
+			names:  []string{"Draft notes 2024 a", "Final notes 2024 b"},
+			anchor: AnchorPrefix,
+			err:    ErrNoCommonPrefix,
+		},
+		{
+			name:   "Prefix_TagIsPartOfStart",
+			names:  []string{"[Draft] ab_report x.txt", "[Final] ab_summary y.txt"},
+			anchor: AnchorPrefix,
+			err:    ErrNoCommonPrefix,
+		},
+		{
+			name:     "Prefix_LeadingTrim",
+			names:    []string{"__ab_report x.txt", "_ab_summary y.txt"},
+			anchor:   AnchorPrefix,
+			expected: "ab",
+		},
+		{
+			name:     "Suffix_TrailingTag",
+			names:    []string{"invoice-0042 (ACME).pdf", "receipt-77 (ACME).pdf", "statement (ACME).PDF"},
+			anchor:   AnchorSuffix,
+			expected: "ACME",
+		},
+		{
+			name:     "Suffix_NoExtension",
+			names:    []string{"invoice-0042 ACME", "receipt-77 ACME"},
+			anchor:   AnchorSuffix,
+			expected: "ACME",
+		},
+		{
+			name:   "Suffix_NoSharedEnd",
+			names:  []string{"ACME invoice.pdf", "ACME receipt.pdf"},
+			anchor: AnchorSuffix,
+			err:    ErrNoCommonPrefix,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			detector := NewDetector(WithTrim("-_ .()"), WithMinLength(2), WithAnchor(test.anchor))
+			result, err := detector.Detect(test.names)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Detect(%q) got error %v; want %v", test.names, err, test.err)
+			}
+			if result.Prefix != test.expected {
+				t.Errorf("Detect(%q) got %q; want %q", test.names, result.Prefix, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseAnchor(t *testing.T) {
+	for _, s := range []string{"", "any", "prefix", "suffix"} {
+		if _, err := ParseAnchor(s); err != nil {
+			t.Errorf("ParseAnchor(%q) failed: %v", s, err)
+		}
+	}
+	if _, err := ParseAnchor("middle"); err == nil {
+		t.Errorf("ParseAnchor(\"middle\") succeeded")
+	}
+}
+
+func TestDetectorDetectExtensions(t *testing.T) {
+	tests := []struct {
+		name     string
+		names    []string
+		opts     []Option
+		expected string
+	}{
+		{
+			name:     "Stripped",
+			names:    []string{"[Draft] Report.txt", "[Final] Report.txt"},
+			expected: "Report",
+		},
+		{
+			name:     "Kept",
+			names:    []string{"[Draft] Report.txt", "[Final] Report.txt"},
+			opts:     []Option{WithKeepExtension(true)},
+			expected: "Report.tx",
+		},
+		{
+			name:     "MixedExtensions",
+			names:    []string{"Show A - 01.mkv", "Show A - 01.srt", "Show A - 02.mkv"},
+			expected: "Show A",
+		},
+		{
+			name:     "CompoundStripped",
+			names:    []string{"site.tar.gz", "site.tar.bz2"},
+			expected: "site",
+		},
+		{
+			name:     "CompoundKept",
+			names:    []string{"site.tar.gz", "site.tar.bz2"},
+			opts:     []Option{WithKeepExtension(true)},
+			expected: "site.tar",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := append([]Option{WithTrim("-_ ."), WithMinLength(3)}, test.opts...)
+			result, err := NewDetector(opts...).Detect(test.names)
+			if err != nil {
+				t.Fatalf("Detect(%q) failed: %v", test.names, err)
+			}
+			if result.Prefix != test.expected {
+				t.Errorf("Detect(%q) got %q; want %q", test.names, result.Prefix, test.expected)
+			}
+		})
+	}
+}
+
+func TestDetectorDetectMinShare(t *testing.T) {
+	names := []string{"Report 234 - Draft1.txt", "Report 234 - Draft2.txt", "Report 234 - Final.txt", "Report 234 - Notes.txt", "IMG_0001.jpg"}
+	tests := []struct {
+		name      string
+		names     []string
+		minShare  float64
+		expected  string
+		unmatched []string
+		err       error
+	}{
+		{name: "AllRequired", names: names, minShare: 0, err: ErrNoCommonPrefix},
+		{name: "Majority", names: names, minShare: 0.8, expected: "Report 234", unmatched: []string{"IMG_0001.jpg"}},
+		{name: "TooFewShare", names: names, minShare: 0.9, err: ErrNoCommonPrefix},
+		{name: "OutlierFirst", names: []string{"IMG_0001.jpg", "Show - 01.mkv", "Show - 02.mkv"}, minShare: 0.6, expected: "Show", unmatched: []string{"IMG_0001.jpg"}},
+		{name: "AtLeastTwo", names: []string{"alpha", "xyz"}, minShare: 0.1, err: ErrNoCommonPrefix},
+		{name: "PreferMoreNames", names: []string{"xShow 1", "xShow 2", "Show 3"}, minShare: 0.6, expected: "Show"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			detector := NewDetector(WithTrim("_- "), WithMinLength(3), WithMinShare(test.minShare))
+			result, err := detector.Detect(test.names)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Detect(%q) got error %v; want %v", test.names, err, test.err)
+			}
+			if result.Prefix != test.expected || !reflect.DeepEqual(result.Unmatched, test.unmatched) {
+				t.Errorf("Detect(%q) got %q leaving out %q; want %q leaving out %q", test.names, result.Prefix, result.Unmatched, test.expected, test.unmatched)
+			}
+			if err == nil && len(result.Matches)+len(result.Unmatched) != len(test.names) {
+				t.Errorf("Detect(%q) got %d matches and %d unmatched names", test.names, len(result.Matches), len(result.Unmatched))
+			}
+		})
+	}
+}
+
+func TestDetectorDetectCandidates(t *testing.T) {
+	detector := NewDetector(WithTrim("_- "), WithMinLength(3))
+	tests := []struct {
+		name     string
+		names    []string
+		n        int
+		expected []string
+		err      error
+	}{
+		{name: "Ranked", names: []string{"Draft - Report 234 v1.txt", "Draft - Report 234 v2.txt"}, n: 5, expected: []string{"Draft", "Report 234 v"}},
+		{name: "Limited", names: []string{"Draft - Report 234 v1.txt", "Draft - Report 234 v2.txt"}, n: 1, expected: []string{"Draft"}},
+		{name: "All", names: []string{"Draft - Report 234 v1.txt", "Draft - Report 234 v2.txt"}, n: 0, expected: []string{"Draft", "Report 234 v"}},
+		{name: "Single", names: []string{"Report 234 - Draft1.txt", "Report 234 - Final.txt"}, n: 5, expected: []string{"Report 234"}},
+		{name: "Empty", names: nil, n: 5, err: ErrTooFewFiles},
+		{name: "NoCommonPrefix", names: []string{"alpha", "xyz"}, n: 5, err: ErrNoCommonPrefix},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results, err := detector.DetectCandidates(test.names, test.n)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("DetectCandidates(%q) got error %v; want %v", test.names, err, test.err)
+			}
+			var prefixes []string
+			for _, result := range results {
+				prefixes = append(prefixes, result.Prefix)
+			}
+			if !reflect.DeepEqual(prefixes, test.expected) {
+				t.Errorf("DetectCandidates(%q) got %q; want %q", test.names, prefixes, test.expected)
+			}
+			if len(results) > 0 {
+				if best, _ := detector.Detect(test.names); !reflect.DeepEqual(results[0], best) {
+					t.Errorf("DetectCandidates(%q) ranked %#v first; Detect got %#v", test.names, results[0], best)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package mvcommon
+
+import "errors"
+
+var (
+	// ErrTooFewFiles is returned when fewer than two files are given, there is nothing to share a prefix with.
+	ErrTooFewFiles = errors.New("at least two files required")
+	// ErrNoCommonPrefix is returned when no common prefix could be detected.
+	ErrNoCommonPrefix = errors.New("no common prefix found")
+	// ErrNoSelection is returned when the user selected no files to move.
+	ErrNoSelection = errors.New("no files selected")
+)
@@ -0,0 +1,38 @@
+package mvcommon
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// maxExtLength is the longest text after the last dot that is still taken for an extension.
+const maxExtLength = 10
+
+// CompoundExts are the extensions made of several parts that Ext returns whole.
+var CompoundExts = []string{".tar.gz", ".tar.bz2", ".tar.xz", ".tar.zst", ".tar.lz", ".tar.lzma", ".tar.Z"}
+
+// Ext returns the extension of name including its dot, like filepath.Ext, but only when it looks like one: 1 to
+// maxExtLength letters or digits. "Report 2.5 final" has no extension. One of CompoundExts, matched regardless of case,
+// is returned whole, so "backup.tar.gz" has the extension ".tar.gz".
+func Ext(name string) string {
+	for _, compound := range CompoundExts {
+		if len(name) > len(compound) && strings.EqualFold(name[len(name)-len(compound):], compound) {
+			return name[len(name)-len(compound):]
+		}
+	}
+	ext := filepath.Ext(name)
+	if len(ext) < 2 || len(ext) > maxExtLength+1 || ext == name {
+		return ""
+	}
+	if strings.ContainsFunc(ext[1:], func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		return ""
+	}
+	return ext
+}
+
+// ExtFolder returns the name of the sub folder files with the extension of name go in when grouping by extension: the
+// extension in lower case without its leading dot, or "" if name has none.
+func ExtFolder(name string) string {
+	return strings.ToLower(strings.TrimPrefix(Ext(filepath.Base(name)), "."))
+}
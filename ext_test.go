@@ -0,0 +1,49 @@
+package mvcommon
+
+import "testing"
+
+func TestExt(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "report.pdf", expected: ".pdf"},
+		{name: "Show - 01.MKV", expected: ".MKV"},
+		{name: "notes", expected: ""},
+		{name: ".bashrc", expected: ""},
+		{name: "trailing.", expected: ""},
+		{name: "Report 2.5 final", expected: ""},
+		{name: "archive.verylongextension", expected: ""},
+		{name: "backup 2024.tar.gz", expected: ".tar.gz"},
+		{name: "backup 2024.TAR.GZ", expected: ".TAR.GZ"},
+		{name: "report.draft.txt", expected: ".txt"},
+		{name: ".tar.gz", expected: ".gz"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Ext(test.name); got != test.expected {
+				t.Errorf("Ext(%q) got %q; want %q", test.name, got, test.expected)
+			}
+		})
+	}
+}
+
+func TestExtFolder(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "Show - 01.MKV", expected: "mkv"},
+		{name: "dir.d/backup.tar.gz", expected: "tar.gz"},
+		{name: "dir.d/notes", expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ExtFolder(test.name); got != test.expected {
+				t.Errorf("ExtFolder(%q) got %q; want %q", test.name, got, test.expected)
+			}
+		})
+	}
+}
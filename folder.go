@@ -0,0 +1,109 @@
+package mvcommon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// FolderData is the data a FolderTemplate is rendered with.
+type FolderData struct {
+	// Prefix is the detected common prefix.
+	Prefix string
+	// Files are the files moved into the folder.
+	Files []string
+	// Time is the earliest modification time of Files, or the current time if none could be read. Year, Month and Day
+	// are taken from it.
+	Time  time.Time
+	Year  string
+	Month string
+	Day   string
+}
+
+// NewFolderData builds the FolderData for prefix and files.
+func NewFolderData(prefix string, files []string) FolderData {
+	var earliest time.Time
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if earliest.IsZero() || info.ModTime().Before(earliest) {
+			earliest = info.ModTime()
+		}
+	}
+	if earliest.IsZero() {
+		earliest = time.Now()
+	}
+	return FolderData{
+		Prefix: prefix,
+		Files:  files,
+		Time:   earliest,
+		Year:   earliest.Format("2006"),
+		Month:  earliest.Format("01"),
+		Day:    earliest.Format("02"),
+	}
+}
+
+// FolderTemplateFuncs are the functions available to folder templates in addition to the text/template builtins.
+var FolderTemplateFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"title": titleCase,
+	"trim":  strings.TrimSpace,
+	"replace": func(old, new, s string) string {
+		return strings.ReplaceAll(s, old, new)
+	},
+}
+
+// FolderTemplate renders folder names from a text/template such as "{{.Year}}/{{.Prefix | lower}}".
+type FolderTemplate struct {
+	tmpl *template.Template
+}
+
+// ParseFolderTemplate parses text as a FolderTemplate.
+func ParseFolderTemplate(text string) (*FolderTemplate, error) {
+	tmpl, err := template.New("folder").Funcs(FolderTemplateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid folder template: %w", err)
+	}
+	return &FolderTemplate{tmpl: tmpl}, nil
+}
+
+// Render renders the template with data. "/" separates folder levels on every platform. The result must be a relative
+// path that stays inside the destination root.
+func (t *FolderTemplate) Render(data FolderData) (string, error) {
+	var sb strings.Builder
+	if err := t.tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render folder template: %w", err)
+	}
+	folder := filepath.Clean(filepath.FromSlash(strings.TrimSpace(sb.String())))
+	switch {
+	case folder == ".":
+		return "", errors.New("folder template rendered an empty folder name")
+	case filepath.IsAbs(folder), folder == "..", strings.HasPrefix(folder, ".."+string(filepath.Separator)):
+		return "", fmt.Errorf("folder template rendered %q which is outside the destination", folder)
+	}
+	return folder, nil
+}
+
+func titleCase(s string) string {
+	runes := []rune(s)
+	start := true
+	for i, r := range runes {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start {
+				runes[i] = unicode.ToUpper(r)
+			}
+			start = false
+		} else {
+			start = true
+		}
+	}
+	return string(runes)
+}
@@ -0,0 +1,61 @@
+package mvcommon
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFolderTemplateRender(t *testing.T) {
+	data := FolderData{
+		Prefix: "Report 234",
+		Time:   time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC),
+		Year:   "2024",
+		Month:  "02",
+		Day:    "03",
+	}
+	tests := []struct {
+		name     string
+		template string
+		expected string
+		wantErr  bool
+	}{
+		{name: "Default", template: DefaultFolderTemplate, expected: "Report 234"},
+		{name: "Lower", template: "{{.Prefix | lower}}", expected: "report 234"},
+		{name: "Replace", template: `{{.Prefix | replace " " "_" | upper}}`, expected: "REPORT_234"},
+		{name: "Title", template: `{{"big show" | title}}`, expected: "Big Show"},
+		{name: "Nested", template: "{{.Year}}/{{.Month}}/{{.Prefix}}", expected: filepath.Join("2024", "02", "Report 234")},
+		{name: "Empty", template: "{{.Missing}}", wantErr: true},
+		{name: "Blank", template: " ", wantErr: true},
+		{name: "Escapes", template: "../{{.Prefix}}", wantErr: true},
+		{name: "Absolute", template: "/{{.Prefix}}", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmpl, err := ParseFolderTemplate(test.template)
+			if err != nil {
+				t.Fatalf("ParseFolderTemplate(%q) failed: %v", test.template, err)
+			}
+			result, err := tmpl.Render(data)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("Render(%q) got %q; want error", test.template, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render(%q) failed: %v", test.template, err)
+			}
+			if result != test.expected {
+				t.Errorf("Render(%q) got %q; want %q", test.template, result, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseFolderTemplateInvalid(t *testing.T) {
+	if _, err := ParseFolderTemplate("{{.Prefix"); err == nil {
+		t.Errorf("ParseFolderTemplate accepted an unterminated action")
+	}
+}
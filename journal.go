@@ -0,0 +1,291 @@
+package mvcommon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// JournalEntryKind identifies what a JournalEntry records.
+type JournalEntryKind string
+
+const (
+	JournalMkdir JournalEntryKind = "mkdir"
+	JournalMove  JournalEntryKind = "move"
+	JournalUndo  JournalEntryKind = "undo"
+)
+
+// ErrNothingToUndo is returned by Undo when the journal holds no run that can be undone.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// JournalEntry is a single line of the journal. Move entries record the size and modification time of the moved file
+// so that Undo can refuse to restore files that have since changed, and whether the move replaced a file that was
+// already at the destination, which Undo cannot bring back.
+type JournalEntry struct {
+	RunID       string           `json:"run_id"`
+	Time        time.Time        `json:"time"`
+	Kind        JournalEntryKind `json:"kind"`
+	Source      string           `json:"source,omitempty"`
+	Destination string           `json:"destination,omitempty"`
+	Size        int64            `json:"size,omitempty"`
+	ModTime     time.Time        `json:"mod_time,omitzero"`
+	Replaced    bool             `json:"replaced,omitempty"`
+}
+
+// Journal appends the entries of a single run to a JSON lines file.
+type Journal struct {
+	Path  string
+	RunID string
+}
+
+// NewJournal returns a Journal writing to path under a new run ID.
+func NewJournal(path string) *Journal {
+	return &Journal{
+		Path:  path,
+		RunID: time.Now().UTC().Format("20060102T150405.000000000"),
+	}
+}
+
+// StateDir returns the per-user directory mvcommon keeps its state in, $XDG_STATE_HOME/mvcommon or
+// ~/.local/state/mvcommon.
+func StateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "mvcommon"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find state directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "state", "mvcommon"), nil
+}
+
+// DefaultJournalPath returns the location of the journal inside StateDir.
+func DefaultJournalPath() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "journal.jsonl"), nil
+}
+
+// Record appends entry to the journal, filling in the run ID and time.
+func (j *Journal) Record(entry JournalEntry) error {
+	entry.RunID = j.RunID
+	entry.Time = time.Now().UTC()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create journal folder: %w", err)
+	}
+	f, err := os.OpenFile(j.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return f.Close()
+}
+
+func (j *Journal) recordMkdir(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	return j.Record(JournalEntry{Kind: JournalMkdir, Destination: abs})
+}
+
+func (j *Journal) recordMove(source, destination string, replaced bool) error {
+	absSource, err := filepath.Abs(source)
+	if err != nil {
+		return err
+	}
+	absDestination, err := filepath.Abs(destination)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(destination)
+	if err != nil {
+		return err
+	}
+	return j.Record(JournalEntry{
+		Kind:        JournalMove,
+		Source:      absSource,
+		Destination: absDestination,
+		Size:        info.Size(),
+		ModTime:     info.ModTime(),
+		Replaced:    replaced,
+	})
+}
+
+// ReadJournal reads every entry of the journal at path. A missing journal holds no entries.
+func ReadJournal(path string) ([]JournalEntry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("journal line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// missingDirs returns the directories MkdirAll would have to create for dir, outermost first.
+func missingDirs(dir string) []string {
+	var missing []string
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		missing = append(missing, dir)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	slices.Reverse(missing)
+	return missing
+}
+
+// UndoOptions configures Undo.
+type UndoOptions struct {
+	DryRun bool
+	// Out receives a line for every restored file, removed folder and file that cannot be restored, defaults to
+	// os.Stdout.
+	Out io.Writer
+}
+
+// Undo reverses the run runID recorded in the journal at path, or the last run that has not been undone when runID is
+// empty. Files are moved back in reverse order and folders created by the run are removed once empty. Undo refuses to
+// touch anything if a moved file has changed or its original location has been reused since the run. Files the run
+// replaced under ConflictOverwrite, ConflictKeepNewer or ConflictKeepLarger are gone, Undo warns about each of them
+// before restoring the rest. It returns the ID of the undone run.
+func Undo(path string, runID string, opts UndoOptions) (string, error) {
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	entries, err := ReadJournal(path)
+	if err != nil {
+		return "", err
+	}
+
+	undone := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Kind == JournalUndo {
+			undone[entry.RunID] = true
+		}
+	}
+	if runID == "" {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if entries[i].Kind != JournalUndo && !undone[entries[i].RunID] {
+				runID = entries[i].RunID
+				break
+			}
+		}
+		if runID == "" {
+			return "", ErrNothingToUndo
+		}
+	} else if undone[runID] {
+		return "", fmt.Errorf("run %s has already been undone", runID)
+	}
+
+	var run []JournalEntry
+	for _, entry := range entries {
+		if entry.RunID == runID && entry.Kind != JournalUndo {
+			run = append(run, entry)
+		}
+	}
+	if len(run) == 0 {
+		return "", fmt.Errorf("run %s not found in journal", runID)
+	}
+
+	var problems []string
+	for _, entry := range run {
+		if entry.Kind != JournalMove {
+			continue
+		}
+		info, err := os.Stat(entry.Destination)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s is missing", entry.Destination))
+			continue
+		}
+		if info.Size() != entry.Size || !info.ModTime().Equal(entry.ModTime) {
+			problems = append(problems, fmt.Sprintf("%s has changed since it was moved", entry.Destination))
+		}
+		if _, err := os.Lstat(entry.Source); err == nil {
+			problems = append(problems, fmt.Sprintf("%s already exists", entry.Source))
+		}
+	}
+	if len(problems) > 0 {
+		return "", fmt.Errorf("refusing to undo run %s: %s", runID, strings.Join(problems, "; "))
+	}
+
+	for _, entry := range run {
+		if entry.Kind == JournalMove && entry.Replaced {
+			fmt.Fprintf(out, "Warning: cannot restore the file %s replaced when it was moved\n", entry.Destination)
+		}
+	}
+
+	for _, entry := range slices.Backward(run) {
+		switch entry.Kind {
+		case JournalMove:
+			if opts.DryRun {
+				fmt.Fprintf(out, "[Dry Run] Would restore %s -> %s\n", entry.Destination, entry.Source)
+				continue
+			}
+			if err := moveFile(entry.Destination, entry.Source, ApplyOptions{}); err != nil {
+				return "", fmt.Errorf("failed to restore file %s: %v", entry.Source, err)
+			}
+			fmt.Fprintf(out, "Restored %s -> %s\n", entry.Destination, entry.Source)
+		case JournalMkdir:
+			if opts.DryRun {
+				fmt.Fprintf(out, "[Dry Run] Would remove folder if empty: %s\n", entry.Destination)
+				continue
+			}
+			contents, err := os.ReadDir(entry.Destination)
+			if err != nil || len(contents) > 0 {
+				continue
+			}
+			if err := os.Remove(entry.Destination); err != nil {
+				return "", fmt.Errorf("failed to remove folder %s: %v", entry.Destination, err)
+			}
+			fmt.Fprintf(out, "Removed folder %s\n", entry.Destination)
+		}
+	}
+
+	if opts.DryRun {
+		return runID, nil
+	}
+	journal := &Journal{Path: path, RunID: runID}
+	if err := journal.Record(JournalEntry{Kind: JournalUndo}); err != nil {
+		return runID, err
+	}
+	return runID, nil
+}
@@ -0,0 +1,158 @@
+package mvcommon
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func applyJournaled(t *testing.T, journalPath string, folder string, files ...string) *Journal {
+	t.Helper()
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create temp file: %v", err)
+		}
+	}
+	journal := NewJournal(journalPath)
+	if err := Apply(PlanMoveToFolder(folder, files), ApplyOptions{Out: io.Discard, Journal: journal}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	return journal
+}
+
+func TestUndo(t *testing.T) {
+	tempDir := t.TempDir()
+	journalPath := filepath.Join(tempDir, "state", "journal.jsonl")
+	files := []string{filepath.Join(tempDir, "file1.txt"), filepath.Join(tempDir, "file2.txt")}
+	folder := filepath.Join(tempDir, "2024", "file")
+	journal := applyJournaled(t, journalPath, folder, files...)
+
+	runID, err := Undo(journalPath, "", UndoOptions{Out: io.Discard})
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if runID != journal.RunID {
+		t.Errorf("Undo() undid run %q; want %q", runID, journal.RunID)
+	}
+	for _, file := range files {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("File %s was not restored: %v", file, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "2024")); !os.IsNotExist(err) {
+		t.Errorf("Created folders were not removed")
+	}
+
+	if _, err := Undo(journalPath, "", UndoOptions{Out: io.Discard}); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Undo() of an undone journal got %v; want %v", err, ErrNothingToUndo)
+	}
+	if _, err := Undo(journalPath, runID, UndoOptions{Out: io.Discard}); err == nil {
+		t.Errorf("Undo() of an undone run succeeded")
+	}
+}
+
+func TestUndoKeepsNonEmptyFolders(t *testing.T) {
+	tempDir := t.TempDir()
+	journalPath := filepath.Join(tempDir, "journal.jsonl")
+	folder := filepath.Join(tempDir, "file")
+	applyJournaled(t, journalPath, folder, filepath.Join(tempDir, "file1.txt"))
+
+	other := filepath.Join(folder, "other.txt")
+	if err := os.WriteFile(other, []byte("other"), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	if _, err := Undo(journalPath, "", UndoOptions{Out: io.Discard}); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("Undo removed a folder that was not empty: %v", err)
+	}
+}
+
+func TestUndoRefusesChangedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	journalPath := filepath.Join(tempDir, "journal.jsonl")
+	folder := filepath.Join(tempDir, "file")
+	files := []string{filepath.Join(tempDir, "file1.txt"), filepath.Join(tempDir, "file2.txt")}
+	applyJournaled(t, journalPath, folder, files...)
+
+	changed := filepath.Join(folder, "file2.txt")
+	if err := os.WriteFile(changed, []byte("changed contents"), 0644); err != nil {
+		t.Fatalf("Failed to change file: %v", err)
+	}
+
+	_, err := Undo(journalPath, "", UndoOptions{Out: io.Discard})
+	if err == nil || !strings.Contains(err.Error(), "has changed") {
+		t.Fatalf("Undo() got %v; want a changed file error", err)
+	}
+	if _, err := os.Stat(filepath.Join(folder, "file1.txt")); err != nil {
+		t.Errorf("Undo moved files despite refusing: %v", err)
+	}
+}
+
+func TestStateDir(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/state")
+	dir, err := StateDir()
+	if err != nil {
+		t.Fatalf("StateDir failed: %v", err)
+	}
+	if dir != filepath.Join("/tmp/state", "mvcommon") {
+		t.Errorf("StateDir() got %q", dir)
+	}
+}
+
+func TestUndoWarnsAboutReplacedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	journalPath := filepath.Join(tempDir, "journal.jsonl")
+	folder := filepath.Join(tempDir, "file")
+	files := []string{filepath.Join(tempDir, "file1.txt"), filepath.Join(tempDir, "file2.txt")}
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create temp file: %v", err)
+		}
+	}
+	replaced := filepath.Join(folder, "file1.txt")
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := os.WriteFile(replaced, []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	plan := PlanMoveToFolder(folder, files)
+	if err := plan.ResolveConflicts(ConflictOverwrite); err != nil {
+		t.Fatalf("ResolveConflicts failed: %v", err)
+	}
+	if err := Apply(plan, ApplyOptions{Out: io.Discard, Journal: NewJournal(journalPath)}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	entries, err := ReadJournal(journalPath)
+	if err != nil {
+		t.Fatalf("ReadJournal failed: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Kind != JournalMove {
+			continue
+		}
+		if want := filepath.Base(entry.Source) == "file1.txt"; entry.Replaced != want {
+			t.Errorf("Journal entry for %s has Replaced %v; want %v", entry.Source, entry.Replaced, want)
+		}
+	}
+
+	var out strings.Builder
+	if _, err := Undo(journalPath, "", UndoOptions{Out: &out}); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if warning := "Warning: cannot restore the file " + replaced + " replaced when it was moved"; !strings.Contains(out.String(), warning) {
+		t.Errorf("Undo() output %q; want it to contain %q", out.String(), warning)
+	}
+	for _, file := range files {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("File %s was not restored: %v", file, err)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package mvcommon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// MatchOn selects which part of a file path detection runs on.
+type MatchOn string
+
+const (
+	// MatchPath matches on the path exactly as given.
+	MatchPath MatchOn = "path"
+	// MatchBase matches on the file name, the default.
+	MatchBase MatchOn = "base"
+	// MatchStem matches on the file name without its extension.
+	MatchStem MatchOn = "stem"
+)
+
+// ParseMatchOn parses the name of a MatchOn.
+func ParseMatchOn(s string) (MatchOn, error) {
+	switch MatchOn(s) {
+	case MatchPath, MatchBase, MatchStem:
+		return MatchOn(s), nil
+	}
+	return "", fmt.Errorf("invalid match mode %q, expected %s, %s or %s", s, MatchPath, MatchBase, MatchStem)
+}
+
+// Anchor selects where in the names a shared match may be.
+type Anchor string
+
+const (
+	// AnchorAny accepts a match anywhere in the names, preferring the earliest, the default.
+	AnchorAny Anchor = "any"
+	// AnchorPrefix only accepts a match at the start of every name, after leading trim characters and stop words.
+	AnchorPrefix Anchor = "prefix"
+	// AnchorSuffix only accepts a match at the end of every name, before the extension and trailing trim characters.
+	AnchorSuffix Anchor = "suffix"
+)
+
+// ParseAnchor parses the name of an Anchor, an empty string is AnchorAny.
+func ParseAnchor(s string) (Anchor, error) {
+	switch Anchor(s) {
+	case "":
+		return AnchorAny, nil
+	case AnchorAny, AnchorPrefix, AnchorSuffix:
+		return Anchor(s), nil
+	}
+	return "", fmt.Errorf("invalid anchor %q, expected %s, %s or %s", s, AnchorPrefix, AnchorSuffix, AnchorAny)
+}
+
+// MatchKey returns the part of file selected by on. An empty MatchOn is treated as MatchBase.
+func MatchKey(file string, on MatchOn) string {
+	switch on {
+	case MatchPath:
+		return file
+	case MatchStem:
+		base := filepath.Base(file)
+		return strings.TrimSuffix(base, Ext(base))
+	default:
+		return filepath.Base(file)
+	}
+}
+
+// MatchKeys returns the MatchKey of every file.
+func MatchKeys(files []string, on MatchOn) []string {
+	keys := make([]string, len(files))
+	for i, file := range files {
+		keys[i] = MatchKey(file, on)
+	}
+	return keys
+}
+
+// ParentPolicy decides where folders are created when the files to move live in different directories.
+type ParentPolicy string
+
+const (
+	// ParentsCommon creates one folder in the destination, or in the nearest directory containing every file.
+	ParentsCommon ParentPolicy = "common"
+	// ParentsPerParent creates the folder inside each directory the files live in.
+	ParentsPerParent ParentPolicy = "per-parent"
+	// ParentsRefuse fails when the files live in more than one directory.
+	ParentsRefuse ParentPolicy = "refuse"
+)
+
+// ErrMultipleParents is returned under ParentsRefuse when files live in more than one directory.
+var ErrMultipleParents = errors.New("files are in more than one directory")
+
+// ParseParentPolicy parses the name of a ParentPolicy.
+func ParseParentPolicy(s string) (ParentPolicy, error) {
+	switch ParentPolicy(s) {
+	case ParentsCommon, ParentsPerParent, ParentsRefuse:
+		return ParentPolicy(s), nil
+	}
+	return "", fmt.Errorf("invalid parents policy %q, expected %s, %s or %s", s, ParentsCommon, ParentsPerParent, ParentsRefuse)
+}
+
+// Placement is a set of files whose folder is created inside Root.
+type Placement struct {
+	Root  string
+	Files []string
+}
+
+// PlaceFiles decides the directory the folder for files is created in according to policy. A non-empty dest is used
+// as the root for every file, except under ParentsPerParent where it is rejected. An empty policy is treated as
+// ParentsCommon.
+func PlaceFiles(files []string, policy ParentPolicy, dest string) ([]Placement, error) {
+	var parents []string
+	byParent := make(map[string][]string)
+	for _, file := range files {
+		parent := filepath.Dir(file)
+		if _, ok := byParent[parent]; !ok {
+			parents = append(parents, parent)
+		}
+		byParent[parent] = append(byParent[parent], file)
+	}
+
+	switch policy {
+	case ParentsPerParent:
+		if dest != "" {
+			return nil, fmt.Errorf("a destination cannot be combined with the %s policy", ParentsPerParent)
+		}
+		placements := make([]Placement, len(parents))
+		for i, parent := range parents {
+			placements[i] = Placement{Root: parent, Files: byParent[parent]}
+		}
+		return placements, nil
+	case ParentsRefuse:
+		if len(parents) > 1 {
+			return nil, fmt.Errorf("%w: %s", ErrMultipleParents, strings.Join(parents, ", "))
+		}
+	case ParentsCommon, "":
+	default:
+		return nil, fmt.Errorf("invalid parents policy %q", policy)
+	}
+
+	if dest == "" {
+		dest = CommonParent(files)
+	}
+	return []Placement{{Root: dest, Files: files}}, nil
+}
+
+// CommonParent returns the nearest directory containing every file. The result is relative to the working directory
+// when every file was given as a relative path.
+func CommonParent(files []string) string {
+	if len(files) == 0 {
+		return "."
+	}
+	relative := true
+	var common []string
+	for i, file := range files {
+		if filepath.IsAbs(file) {
+			relative = false
+		}
+		abs, err := filepath.Abs(filepath.Dir(file))
+		if err != nil {
+			return "."
+		}
+		parts := strings.Split(abs, string(filepath.Separator))
+		if i == 0 {
+			common = parts
+			continue
+		}
+		n := 0
+		for n < len(common) && n < len(parts) && common[n] == parts[n] {
+			n++
+		}
+		common = slices.Clip(common[:n])
+	}
+
+	parent := strings.Join(common, string(filepath.Separator))
+	if parent == "" || (len(common) == 1 && filepath.VolumeName(parent) == parent) {
+		parent += string(filepath.Separator)
+	}
+	if relative {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, parent); err == nil {
+				return rel
+			}
+		}
+	}
+	return parent
+}
@@ -0,0 +1,123 @@
+package mvcommon
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMatchKey(t *testing.T) {
+	file := filepath.Join("downloads", "Show - 01.mkv")
+	tests := []struct {
+		on       MatchOn
+		expected string
+	}{
+		{on: MatchPath, expected: file},
+		{on: MatchBase, expected: "Show - 01.mkv"},
+		{on: MatchStem, expected: "Show - 01"},
+		{on: "", expected: "Show - 01.mkv"},
+	}
+	for _, test := range tests {
+		if got := MatchKey(file, test.on); got != test.expected {
+			t.Errorf("MatchKey(%q, %q) got %q; want %q", file, test.on, got, test.expected)
+		}
+	}
+}
+
+func TestPlaceFiles(t *testing.T) {
+	a := filepath.Join("a", "Show - 01.mkv")
+	a2 := filepath.Join("a", "Show - 02.mkv")
+	b := filepath.Join("a", "b", "Show - 03.mkv")
+	tests := []struct {
+		name     string
+		files    []string
+		policy   ParentPolicy
+		dest     string
+		expected []Placement
+		wantErr  error
+	}{
+		{
+			name:     "Common_SingleParent",
+			files:    []string{a, a2},
+			policy:   ParentsCommon,
+			expected: []Placement{{Root: "a", Files: []string{a, a2}}},
+		},
+		{
+			name:     "Common_NearestAncestor",
+			files:    []string{a, b},
+			policy:   ParentsCommon,
+			expected: []Placement{{Root: "a", Files: []string{a, b}}},
+		},
+		{
+			name:     "Common_Dest",
+			files:    []string{a, b},
+			dest:     "library",
+			expected: []Placement{{Root: "library", Files: []string{a, b}}},
+		},
+		{
+			name:   "PerParent",
+			files:  []string{a, b, a2},
+			policy: ParentsPerParent,
+			expected: []Placement{
+				{Root: "a", Files: []string{a, a2}},
+				{Root: filepath.Join("a", "b"), Files: []string{b}},
+			},
+		},
+		{
+			name:    "PerParent_WithDest",
+			files:   []string{a, b},
+			policy:  ParentsPerParent,
+			dest:    "library",
+			wantErr: errors.New("a destination cannot be combined with the per-parent policy"),
+		},
+		{
+			name:     "Refuse_SingleParent",
+			files:    []string{a, a2},
+			policy:   ParentsRefuse,
+			expected: []Placement{{Root: "a", Files: []string{a, a2}}},
+		},
+		{
+			name:    "Refuse_MultipleParents",
+			files:   []string{a, b},
+			policy:  ParentsRefuse,
+			wantErr: ErrMultipleParents,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := PlaceFiles(test.files, test.policy, test.dest)
+			if test.wantErr != nil {
+				if err == nil || (!errors.Is(err, test.wantErr) && err.Error() != test.wantErr.Error()) {
+					t.Errorf("PlaceFiles() error = %v, wantErr %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PlaceFiles() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("PlaceFiles() got %#v; want %#v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestCommonParent(t *testing.T) {
+	tests := []struct {
+		files    []string
+		expected string
+	}{
+		{files: []string{"file_one.txt", "file_two.txt"}, expected: "."},
+		{files: []string{filepath.Join("a", "b", "x"), filepath.Join("a", "c", "y")}, expected: "a"},
+		{files: []string{filepath.Join("..", "a", "x"), filepath.Join("b", "y")}, expected: ".."},
+		{files: []string{"/srv/media/a/x", "/srv/media/b/y"}, expected: filepath.FromSlash("/srv/media")},
+		{files: []string{"/srv/x", "/home/y"}, expected: filepath.FromSlash("/")},
+	}
+	for _, test := range tests {
+		if got := CommonParent(test.files); got != test.expected {
+			t.Errorf("CommonParent(%q) got %q; want %q", test.files, got, test.expected)
+		}
+	}
+}
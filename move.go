@@ -0,0 +1,143 @@
+package mvcommon
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// VerifyMode decides how a copied file is checked before its source is removed.
+type VerifyMode string
+
+const (
+	// VerifySize compares the size of the copy with the source.
+	VerifySize VerifyMode = "size"
+	// VerifyChecksum additionally compares SHA-256 checksums of the copy and the source.
+	VerifyChecksum VerifyMode = "checksum"
+)
+
+// ParseVerifyMode parses the name of a VerifyMode.
+func ParseVerifyMode(s string) (VerifyMode, error) {
+	switch VerifyMode(s) {
+	case VerifySize, VerifyChecksum:
+		return VerifyMode(s), nil
+	}
+	return "", fmt.Errorf("invalid verify mode %q, expected %s or %s", s, VerifySize, VerifyChecksum)
+}
+
+// rename is os.Rename, replaced in tests to simulate moves across file systems.
+var rename = os.Rename
+
+// moveFile renames source to destination. When they are on different file systems the file is copied instead, and the
+// source is only removed once the copy has been verified.
+func moveFile(source, destination string, opts ApplyOptions) error {
+	err := rename(source, destination)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	return copyVerifyDelete(source, destination, opts)
+}
+
+// copyVerifyDelete streams source into a temporary file next to destination, carries over its mode, modification time
+// and optionally extended attributes, verifies the copy, renames it into place and finally removes source. The
+// temporary file is removed if any step before the final rename fails.
+func copyVerifyDelete(source, destination string, opts ApplyOptions) (err error) {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("cannot copy %s across file systems: not a regular file", source)
+	}
+
+	out, err := os.CreateTemp(filepath.Dir(destination), "."+filepath.Base(destination)+".*.partial")
+	if err != nil {
+		return err
+	}
+	partial := out.Name()
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(partial)
+		}
+	}()
+
+	var sourceSum hash.Hash
+	var w io.Writer = out
+	if opts.Verify == VerifyChecksum {
+		sourceSum = sha256.New()
+		w = io.MultiWriter(out, sourceSum)
+	}
+	if _, err = io.Copy(w, in); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", source, err)
+	}
+	if err = out.Sync(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Chmod(partial, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if opts.PreserveXattrs {
+		if err = copyXattrs(source, partial); err != nil {
+			return fmt.Errorf("failed to copy extended attributes of %s: %w", source, err)
+		}
+	}
+	if err = os.Chtimes(partial, time.Time{}, info.ModTime()); err != nil {
+		return err
+	}
+
+	if err = verifyCopy(partial, info, sourceSum); err != nil {
+		return fmt.Errorf("failed to verify copy of %s: %w", source, err)
+	}
+	if err = os.Rename(partial, destination); err != nil {
+		return err
+	}
+	if err := os.Remove(source); err != nil {
+		return fmt.Errorf("copied %s to %s but failed to remove the original: %w", source, destination, err)
+	}
+	return nil
+}
+
+// verifyCopy checks that the file at path matches the size of source and, when sourceSum is set, its checksum.
+func verifyCopy(path string, source os.FileInfo, sourceSum hash.Hash) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() != source.Size() {
+		return fmt.Errorf("size mismatch: copied %d of %d bytes", info.Size(), source.Size())
+	}
+	if sourceSum == nil {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	copySum := sha256.New()
+	if _, err := io.Copy(copySum, f); err != nil {
+		return err
+	}
+	if !bytes.Equal(copySum.Sum(nil), sourceSum.Sum(nil)) {
+		return errors.New("checksum mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package mvcommon
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func simulateCrossDevice(t *testing.T) {
+	t.Helper()
+	rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() {
+		rename = os.Rename
+	})
+}
+
+func TestMoveFileAcrossFileSystems(t *testing.T) {
+	for _, verify := range []VerifyMode{VerifySize, VerifyChecksum} {
+		t.Run(string(verify), func(t *testing.T) {
+			simulateCrossDevice(t)
+			tempDir := t.TempDir()
+			source := filepath.Join(tempDir, "file1.txt")
+			folder := filepath.Join(tempDir, "output")
+			if err := os.WriteFile(source, []byte("test contents"), 0640); err != nil {
+				t.Fatalf("Failed to create temp file: %v", err)
+			}
+			modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+			if err := os.Chtimes(source, modTime, modTime); err != nil {
+				t.Fatalf("Failed to set time: %v", err)
+			}
+
+			if err := Apply(PlanMoveToFolder(folder, []string{source}), ApplyOptions{Out: io.Discard, Verify: verify}); err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			}
+
+			if _, err := os.Stat(source); !os.IsNotExist(err) {
+				t.Errorf("Source %s was not removed", source)
+			}
+			destination := filepath.Join(folder, "file1.txt")
+			data, err := os.ReadFile(destination)
+			if err != nil || string(data) != "test contents" {
+				t.Fatalf("Destination has %q, %v", data, err)
+			}
+			info, err := os.Stat(destination)
+			if err != nil {
+				t.Fatalf("Failed to stat destination: %v", err)
+			}
+			if info.Mode().Perm() != 0640 {
+				t.Errorf("Mode got %v; want %v", info.Mode().Perm(), os.FileMode(0640))
+			}
+			if !info.ModTime().Equal(modTime) {
+				t.Errorf("ModTime got %v; want %v", info.ModTime(), modTime)
+			}
+			entries, err := os.ReadDir(folder)
+			if err != nil || len(entries) != 1 {
+				t.Errorf("Folder holds %v, %v; want only the moved file", entries, err)
+			}
+		})
+	}
+}
+
+func TestMoveFileAcrossFileSystemsFailureKeepsSource(t *testing.T) {
+	simulateCrossDevice(t)
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "file1.txt")
+	if err := os.WriteFile(source, []byte("test"), 0644); err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+
+	if err := moveFile(source, filepath.Join(tempDir, "missing", "file1.txt"), ApplyOptions{}); err == nil {
+		t.Fatalf("moveFile into a missing folder succeeded")
+	}
+	if _, err := os.Stat(source); err != nil {
+		t.Errorf("Source was removed after a failed copy: %v", err)
+	}
+}
+
+func TestParseVerifyMode(t *testing.T) {
+	for _, mode := range []VerifyMode{VerifySize, VerifyChecksum} {
+		if got, err := ParseVerifyMode(string(mode)); err != nil || got != mode {
+			t.Errorf("ParseVerifyMode(%q) = %q, %v", mode, got, err)
+		}
+	}
+	if _, err := ParseVerifyMode("none"); err == nil {
+		t.Errorf("ParseVerifyMode(%q) succeeded", "none")
+	}
+}
@@ -0,0 +1,114 @@
+package mvcommon
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalization selects the Unicode normalization form names are compared in.
+type Normalization string
+
+const (
+	// NormalizeNone compares names as they are.
+	NormalizeNone Normalization = "none"
+	// NormalizeNFC compares names in composed form, so "é" matches "e" followed by a combining accent.
+	NormalizeNFC Normalization = "nfc"
+	// NormalizeNFD compares names in decomposed form, which matches the same names as NormalizeNFC.
+	NormalizeNFD Normalization = "nfd"
+	// NormalizeNFKC also folds compatibility characters, so full width "Ａ" matches "A" and "ﬁ" matches "fi".
+	NormalizeNFKC Normalization = "nfkc"
+)
+
+// Normalizations lists the valid Normalization values.
+var Normalizations = []Normalization{NormalizeNone, NormalizeNFC, NormalizeNFD, NormalizeNFKC}
+
+// ParseNormalization parses a normalization name, an empty string is NormalizeNone.
+func ParseNormalization(s string) (Normalization, error) {
+	if s == "" {
+		return NormalizeNone, nil
+	}
+	for _, n := range Normalizations {
+		if string(n) == s {
+			return n, nil
+		}
+	}
+	return "", fmt.Errorf("invalid normalization %q, expected one of %v", s, Normalizations)
+}
+
+// transforms reports whether o compares names in another form than they are written.
+func (o Options) transforms() bool {
+	return o.FoldCase || (o.Normalization != "" && o.Normalization != NormalizeNone)
+}
+
+// compareForm returns s in the form names are compared in.
+func (o Options) compareForm(s string) string {
+	if o.FoldCase {
+		s = cases.Fold().String(s)
+	}
+	switch o.Normalization {
+	case NormalizeNFC:
+		s = norm.NFC.String(s)
+	case NormalizeNFD:
+		s = norm.NFD.String(s)
+	case NormalizeNFKC:
+		s = norm.NFKC.String(s)
+	}
+	return s
+}
+
+// segmented is a name split into units, each with the key it is compared by. Unit i is name[bounds[i]:
+// bounds[i+1]] and compares as key[keyBounds[i]:keyBounds[i+1]].
+type segmented struct {
+	name      string
+	bounds    []int
+	key       string
+	keyBounds []int
+}
+
+// segment splits name into the units detection works on. Units are transformed one at a time, so every unit of the
+// key maps back to a unit of name.
+func (o Options) segment(name string) segmented {
+	s := segmented{name: name}
+	if o.Tokenizer != nil {
+		s.bounds = o.Tokenizer(name)
+	} else {
+		s.bounds = graphemeBounds(name)
+	}
+	if !o.transforms() {
+		s.key, s.keyBounds = name, s.bounds
+		return s
+	}
+	var key strings.Builder
+	s.keyBounds = make([]int, 1, len(s.bounds))
+	for i := 0; i < len(s.bounds)-1; i++ {
+		key.WriteString(o.compareForm(name[s.bounds[i]:s.bounds[i+1]]))
+		s.keyBounds = append(s.keyBounds, key.Len())
+	}
+	s.key = key.String()
+	return s
+}
+
+// len returns the number of units.
+func (s segmented) len() int {
+	return len(s.bounds) - 1
+}
+
+// unit returns the key of unit i.
+func (s segmented) unit(i int) string {
+	return s.key[s.keyBounds[i]:s.keyBounds[i+1]]
+}
+
+// unitAt returns the first unit starting at or after byte offset of name.
+func (s segmented) unitAt(offset int) int {
+	i, _ := slices.BinarySearch(s.bounds, offset)
+	return i
+}
+
+// keyOf returns the key of units [from, to).
+func (s segmented) keyOf(from, to int) string {
+	return s.key[s.keyBounds[from]:s.keyBounds[to]]
+}
@@ -0,0 +1,65 @@
+package mvcommon
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ParsePattern compiles a --pattern regular expression used by GroupByPattern.
+func ParsePattern(s string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pattern %q: %w", s, err)
+	}
+	return re, nil
+}
+
+// PatternKey returns the group key pattern extracts from name: the text of the first named capture group that took
+// part in the match, or the whole match if the pattern has no named groups. ok is false if the pattern does not match
+// or the key is empty.
+func PatternKey(name string, pattern *regexp.Regexp) (key string, ok bool) {
+	m := pattern.FindStringSubmatchIndex(name)
+	if m == nil {
+		return "", false
+	}
+	key = name[m[0]:m[1]]
+	for i, group := range pattern.SubexpNames() {
+		if i == 0 || group == "" {
+			continue
+		}
+		key = ""
+		if m[2*i] >= 0 {
+			key = name[m[2*i]:m[2*i+1]]
+			break
+		}
+	}
+	return key, key != ""
+}
+
+// GroupByPattern groups names by the key pattern extracts from the part of each name selected by on, instead of
+// detecting a shared prefix. Groups are returned in the order their key is first seen and may hold a single name.
+// Names the pattern does not match are returned in a final leftover Group with an empty Prefix.
+func GroupByPattern(names []string, pattern *regexp.Regexp, on MatchOn) []Group {
+	var groups []Group
+	var leftover []string
+	index := make(map[string]int)
+	for _, name := range names {
+		key, ok := PatternKey(MatchKey(name, on), pattern)
+		if !ok {
+			leftover = append(leftover, name)
+			continue
+		}
+		i, found := index[key]
+		if !found {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Group{Prefix: key})
+		}
+		groups[i].Names = append(groups[i].Names, name)
+	}
+
+	if len(leftover) > 0 {
+		groups = append(groups, Group{Names: leftover})
+	}
+	return groups
+}
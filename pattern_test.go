@@ -0,0 +1,63 @@
+package mvcommon
+
+import (
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestPatternKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		input    string
+		expected string
+		ok       bool
+	}{
+		{name: "NamedGroup", pattern: `(?P<project>[A-Z]{3}-\d+)`, input: "notes ABC-12 final.txt", expected: "ABC-12", ok: true},
+		{name: "FirstNamedGroup", pattern: `(\d{4})-(?P<client>\w+)-(?P<job>\d+)`, input: "2024-acme-7.pdf", expected: "acme", ok: true},
+		{name: "AlternativeNamedGroup", pattern: `(?P<a>x\d)|(?P<b>y\d)`, input: "file y2", expected: "y2", ok: true},
+		{name: "WholeMatch", pattern: `[A-Z]{3}-\d+`, input: "ABC-12.txt", expected: "ABC-12", ok: true},
+		{name: "NoMatch", pattern: `(?P<project>[A-Z]{3}-\d+)`, input: "readme.md", ok: false},
+		{name: "EmptyKey", pattern: `(?P<project>[A-Z]*)\.txt`, input: "x.txt", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := PatternKey(tt.input, regexp.MustCompile(tt.pattern))
+			if key != tt.expected || ok != tt.ok {
+				t.Errorf("PatternKey(%q) got (%q, %v); want (%q, %v)", tt.input, key, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestGroupByPattern(t *testing.T) {
+	pattern, err := ParsePattern(`(?P<project>[A-Z]{3}-\d+)`)
+	if err != nil {
+		t.Fatalf("ParsePattern failed: %v", err)
+	}
+	names := []string{
+		filepath.Join("ABC-1", "draft ABC-12.txt"),
+		"XYZ-3 scan.pdf",
+		"final ABC-12 v2.txt",
+		"readme.md",
+	}
+
+	got := GroupByPattern(names, pattern, MatchBase)
+	expected := []Group{
+		{Prefix: "ABC-12", Names: []string{names[0], names[2]}},
+		{Prefix: "XYZ-3", Names: []string{names[1]}},
+		{Names: []string{"readme.md"}},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("GroupByPattern() got %#v; want %#v", got, expected)
+	}
+}
+
+func TestParsePatternInvalid(t *testing.T) {
+	if _, err := ParsePattern(`(?P<project>`); err == nil {
+		t.Errorf("ParsePattern accepted an invalid pattern")
+	}
+}
@@ -0,0 +1,270 @@
+package mvcommon
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// OperationKind identifies what an Operation does.
+type OperationKind string
+
+const (
+	OperationMkdir OperationKind = "mkdir"
+	OperationMove  OperationKind = "move"
+)
+
+// OperationStatus is the state of an Operation.
+type OperationStatus string
+
+const (
+	StatusPlanned OperationStatus = "planned"
+	StatusDone    OperationStatus = "done"
+	StatusSkipped OperationStatus = "skipped"
+	StatusFailed  OperationStatus = "failed"
+)
+
+// Operation is a single step of a Plan.
+type Operation struct {
+	Kind        OperationKind `json:"kind"`
+	Source      string        `json:"source,omitempty"`
+	Destination string        `json:"destination"`
+	Reason      string        `json:"reason,omitempty"`
+	// Conflict describes how an existing destination was handled, see Plan.ResolveConflicts.
+	Conflict  string `json:"conflict,omitempty"`
+	Skip      bool   `json:"skip,omitempty"`
+	Overwrite bool   `json:"overwrite,omitempty"`
+	// Status is updated by Apply, Error holds the reason of a StatusFailed operation.
+	Status OperationStatus `json:"status"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Plan is an ordered list of operations describing how files will be moved. A Plan is produced by detection and
+// consumed by Apply, so dry runs, confirmation prompts and exports all see the same set of operations.
+type Plan struct {
+	Operations []Operation `json:"operations"`
+}
+
+// PlanMoveToFolder creates a Plan that creates folder and moves files into it.
+func PlanMoveToFolder(folder string, files []string) *Plan {
+	plan := &Plan{}
+	plan.AddFolder(folder, files, fmt.Sprintf("common prefix %q", filepath.Base(folder)))
+	return plan
+}
+
+// AddFolder appends the operations required to create folder, unless the Plan already creates it, and to move files
+// into it. Each operation is given reason.
+func (p *Plan) AddFolder(folder string, files []string, reason string) {
+	if !p.Creates(folder) {
+		p.Operations = append(p.Operations, Operation{
+			Kind:        OperationMkdir,
+			Destination: folder,
+			Reason:      reason,
+			Status:      StatusPlanned,
+		})
+	}
+	for _, file := range files {
+		p.Operations = append(p.Operations, Operation{
+			Kind:        OperationMove,
+			Source:      file,
+			Destination: filepath.Join(folder, filepath.Base(file)),
+			Reason:      reason,
+			Status:      StatusPlanned,
+		})
+	}
+}
+
+// PlanOptions configures PlanGroups.
+type PlanOptions struct {
+	// Dest is the directory folders are created in, see PlaceFiles.
+	Dest string
+	// Template renders the folder name of each group, defaults to DefaultFolderTemplate.
+	Template *FolderTemplate
+	// Parents decides where folders go when the files of a group live in different directories.
+	Parents ParentPolicy
+	// GroupByExt moves files into a sub folder per extension inside their group's folder, see ExtFolder. Files without
+	// an extension stay in the group's folder.
+	GroupByExt bool
+}
+
+// PlanGroups plans moving the files of every group into a folder named after its prefix. Groups without a prefix are
+// left alone.
+func PlanGroups(groups []Group, opts PlanOptions) (*Plan, error) {
+	tmpl := opts.Template
+	if tmpl == nil {
+		var err error
+		if tmpl, err = ParseFolderTemplate(DefaultFolderTemplate); err != nil {
+			return nil, err
+		}
+	}
+
+	plan := &Plan{}
+	for _, group := range groups {
+		if group.Prefix == "" {
+			continue
+		}
+		folder, err := tmpl.Render(NewFolderData(group.Prefix, group.Names))
+		if err != nil {
+			return nil, err
+		}
+		placements, err := PlaceFiles(group.Names, opts.Parents, opts.Dest)
+		if err != nil {
+			return nil, err
+		}
+		reason := fmt.Sprintf("common prefix %q", group.Prefix)
+		for _, placement := range placements {
+			target := filepath.Join(placement.Root, folder)
+			if !opts.GroupByExt {
+				plan.AddFolder(target, placement.Files, reason)
+				continue
+			}
+			byExt := make(map[string][]string)
+			var exts []string
+			for _, file := range placement.Files {
+				ext := ExtFolder(file)
+				if _, ok := byExt[ext]; !ok {
+					exts = append(exts, ext)
+				}
+				byExt[ext] = append(byExt[ext], file)
+			}
+			for _, ext := range exts {
+				plan.AddFolder(filepath.Join(target, ext), byExt[ext], reason)
+			}
+		}
+	}
+	return plan, nil
+}
+
+// Creates reports whether the Plan contains a mkdir operation for folder.
+func (p *Plan) Creates(folder string) bool {
+	for _, op := range p.Operations {
+		if op.Kind == OperationMkdir && op.Destination == folder {
+			return true
+		}
+	}
+	return false
+}
+
+// Moves returns the move operations of the Plan.
+func (p *Plan) Moves() []Operation {
+	var moves []Operation
+	for _, op := range p.Operations {
+		if op.Kind == OperationMove {
+			moves = append(moves, op)
+		}
+	}
+	return moves
+}
+
+// WriteDryRun describes the operations of the Plan to w without performing them.
+func (p *Plan) WriteDryRun(w io.Writer) error {
+	for _, op := range p.Operations {
+		var err error
+		switch op.Kind {
+		case OperationMkdir:
+			_, err = fmt.Fprintf(w, "[Dry Run] Would create folder: %s\n", op.Destination)
+		case OperationMove:
+			switch {
+			case op.Skip:
+				_, err = fmt.Fprintf(w, "[Dry Run] Would skip %s: %s\n", op.Source, op.Conflict)
+			case op.Conflict != "":
+				_, err = fmt.Fprintf(w, "[Dry Run] Would move %s -> %s (%s)\n", op.Source, op.Destination, op.Conflict)
+			default:
+				_, err = fmt.Fprintf(w, "[Dry Run] Would move %s -> %s\n", op.Source, op.Destination)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyOptions configures Apply.
+type ApplyOptions struct {
+	// Out receives a line for every completed move, defaults to os.Stdout.
+	Out io.Writer
+	// Journal, when set, records every created folder and moved file so the run can be undone.
+	Journal *Journal
+	// Verify decides how files copied across file systems are checked before their source is removed, defaults to
+	// VerifySize.
+	Verify VerifyMode
+	// PreserveXattrs copies extended attributes of files copied across file systems.
+	PreserveXattrs bool
+	// OnOperation, when set, is called with every operation as soon as it has been performed, skipped or has failed.
+	OnOperation func(op Operation)
+}
+
+// Apply performs the operations of plan in order, stopping at the first failure. The Status of every performed
+// operation is updated. A move onto an existing destination fails unless the operation was marked to overwrite by
+// Plan.ResolveConflicts.
+func Apply(plan *Plan, opts ApplyOptions) error {
+	out := opts.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	for i := range plan.Operations {
+		op := &plan.Operations[i]
+		err := applyOperation(op, out, opts)
+		switch {
+		case err != nil:
+			op.Status = StatusFailed
+			op.Error = err.Error()
+		case op.Skip:
+			op.Status = StatusSkipped
+		default:
+			op.Status = StatusDone
+		}
+		if opts.OnOperation != nil {
+			opts.OnOperation(*op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyOperation(op *Operation, out io.Writer, opts ApplyOptions) error {
+	switch op.Kind {
+	case OperationMkdir:
+		created := missingDirs(op.Destination)
+		if err := os.MkdirAll(op.Destination, 0755); err != nil {
+			return fmt.Errorf("failed to create folder %s: %v", op.Destination, err)
+		}
+		if opts.Journal != nil {
+			for _, dir := range created {
+				if err := opts.Journal.recordMkdir(dir); err != nil {
+					return fmt.Errorf("failed to journal folder %s: %v", dir, err)
+				}
+			}
+		}
+	case OperationMove:
+		if op.Skip {
+			fmt.Fprintf(out, "Skipped %s: %s\n", op.Source, op.Conflict)
+			return nil
+		}
+		_, err := os.Lstat(op.Destination)
+		replaced := err == nil
+		if replaced && !op.Overwrite {
+			return fmt.Errorf("failed to move file %s: %w: %s", op.Source, ErrDestinationExists, op.Destination)
+		}
+		if err := moveFile(op.Source, op.Destination, opts); err != nil {
+			return fmt.Errorf("failed to move file %s: %v", op.Source, err)
+		}
+		if opts.Journal != nil {
+			if err := opts.Journal.recordMove(op.Source, op.Destination, replaced); err != nil {
+				return fmt.Errorf("failed to journal move of %s: %v", op.Source, err)
+			}
+		}
+		if op.Conflict != "" {
+			fmt.Fprintf(out, "Moved %s -> %s (%s)\n", op.Source, op.Destination, op.Conflict)
+		} else {
+			fmt.Fprintf(out, "Moved %s -> %s\n", op.Source, op.Destination)
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", op.Kind)
+	}
+	return nil
+}
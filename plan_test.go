@@ -0,0 +1,152 @@
+package mvcommon
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPlanAddFolder(t *testing.T) {
+	plan := &Plan{}
+	plan.AddFolder("Report 234", []string{"Report 234 - Draft1.txt"}, "first")
+	plan.AddFolder("Report 234", []string{"dir/Report 234 - Final.txt"}, "second")
+
+	expected := []Operation{
+		{Kind: OperationMkdir, Destination: "Report 234", Reason: "first", Status: StatusPlanned},
+		{Kind: OperationMove, Source: "Report 234 - Draft1.txt", Destination: filepath.Join("Report 234", "Report 234 - Draft1.txt"), Reason: "first", Status: StatusPlanned},
+		{Kind: OperationMove, Source: "dir/Report 234 - Final.txt", Destination: filepath.Join("Report 234", "Report 234 - Final.txt"), Reason: "second", Status: StatusPlanned},
+	}
+	if !reflect.DeepEqual(plan.Operations, expected) {
+		t.Errorf("AddFolder() got %#v; want %#v", plan.Operations, expected)
+	}
+	if got := len(plan.Moves()); got != 2 {
+		t.Errorf("Moves() got %d operations; want 2", got)
+	}
+}
+
+func TestPlanWriteDryRun(t *testing.T) {
+	plan := PlanMoveToFolder("file", []string{"file_one.txt", "file_two.txt"})
+
+	var buf bytes.Buffer
+	if err := plan.WriteDryRun(&buf); err != nil {
+		t.Fatalf("WriteDryRun failed: %v", err)
+	}
+	expected := "[Dry Run] Would create folder: file\n" +
+		"[Dry Run] Would move file_one.txt -> " + filepath.Join("file", "file_one.txt") + "\n" +
+		"[Dry Run] Would move file_two.txt -> " + filepath.Join("file", "file_two.txt") + "\n"
+	if buf.String() != expected {
+		t.Errorf("WriteDryRun() got %q; want %q", buf.String(), expected)
+	}
+}
+
+func TestApply(t *testing.T) {
+	tempDir := t.TempDir()
+	files := []string{
+		filepath.Join(tempDir, "file1.txt"),
+		filepath.Join(tempDir, "file2.txt"),
+	}
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create temp file: %v", err)
+		}
+	}
+
+	folder := filepath.Join(tempDir, "output")
+	var buf bytes.Buffer
+	var reported []Operation
+	plan := PlanMoveToFolder(folder, files)
+	if err := Apply(plan, ApplyOptions{Out: &buf, OnOperation: func(op Operation) { reported = append(reported, op) }}); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if !reflect.DeepEqual(reported, plan.Operations) {
+		t.Errorf("OnOperation got %#v; want %#v", reported, plan.Operations)
+	}
+	for _, op := range plan.Operations {
+		if op.Status != StatusDone {
+			t.Errorf("Operation %#v has status %q; want %q", op, op.Status, StatusDone)
+		}
+	}
+
+	for _, file := range files {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Errorf("File %s was not moved away", file)
+		}
+		if _, err := os.Stat(filepath.Join(folder, filepath.Base(file))); err != nil {
+			t.Errorf("File %s was not moved: %v", file, err)
+		}
+	}
+	if buf.Len() == 0 {
+		t.Errorf("Apply wrote no output")
+	}
+}
+
+func TestPlanGroups(t *testing.T) {
+	tmpl, err := ParseFolderTemplate("{{.Prefix | lower}}")
+	if err != nil {
+		t.Fatalf("ParseFolderTemplate failed: %v", err)
+	}
+	groups := []Group{
+		{Prefix: "Show", Names: []string{filepath.Join("a", "Show - 01.mkv"), filepath.Join("b", "Show - 02.mkv")}},
+		{Names: []string{"notes.md"}},
+	}
+
+	plan, err := PlanGroups(groups, PlanOptions{Template: tmpl, Parents: ParentsPerParent})
+	if err != nil {
+		t.Fatalf("PlanGroups failed: %v", err)
+	}
+	var destinations []string
+	for _, op := range plan.Operations {
+		destinations = append(destinations, op.Destination)
+	}
+	expected := []string{
+		filepath.Join("a", "show"),
+		filepath.Join("a", "show", "Show - 01.mkv"),
+		filepath.Join("b", "show"),
+		filepath.Join("b", "show", "Show - 02.mkv"),
+	}
+	if !reflect.DeepEqual(destinations, expected) {
+		t.Errorf("PlanGroups() got destinations %q; want %q", destinations, expected)
+	}
+}
+
+func TestApplyReportsFailure(t *testing.T) {
+	tempDir := t.TempDir()
+	plan := PlanMoveToFolder(filepath.Join(tempDir, "output"), []string{filepath.Join(tempDir, "missing.txt")})
+	if err := Apply(plan, ApplyOptions{Out: io.Discard}); err == nil {
+		t.Fatalf("Apply of a missing file succeeded")
+	}
+	op := plan.Moves()[0]
+	if op.Status != StatusFailed || op.Error == "" {
+		t.Errorf("Apply() left failed operation as %#v", op)
+	}
+}
+
+func TestPlanGroupsByExt(t *testing.T) {
+	groups := []Group{
+		{Prefix: "Show", Names: []string{"Show - 01.mkv", "Show - 01.srt", "Show - 02.MKV", "Show - notes"}},
+	}
+
+	plan, err := PlanGroups(groups, PlanOptions{GroupByExt: true})
+	if err != nil {
+		t.Fatalf("PlanGroups failed: %v", err)
+	}
+	var destinations []string
+	for _, op := range plan.Operations {
+		destinations = append(destinations, op.Destination)
+	}
+	expected := []string{
+		filepath.Join("Show", "mkv"),
+		filepath.Join("Show", "mkv", "Show - 01.mkv"),
+		filepath.Join("Show", "mkv", "Show - 02.MKV"),
+		filepath.Join("Show", "srt"),
+		filepath.Join("Show", "srt", "Show - 01.srt"),
+		"Show",
+		filepath.Join("Show", "Show - notes"),
+	}
+	if !reflect.DeepEqual(destinations, expected) {
+		t.Errorf("PlanGroups() got destinations %q; want %q", destinations, expected)
+	}
+}
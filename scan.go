@@ -0,0 +1,90 @@
+package mvcommon
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// ScanOptions configures Scan.
+type ScanOptions struct {
+	// Recursive descends into sub directories.
+	Recursive bool
+	// MaxDepth limits how many directories deep a recursive scan goes, 0 means no limit.
+	MaxDepth int
+	// Include restricts the scan to files whose name matches one of these filepath.Match patterns.
+	Include []string
+	// Exclude skips files and directories whose name matches one of these filepath.Match patterns.
+	Exclude []string
+	// Hidden includes files and directories whose name starts with a dot.
+	Hidden bool
+}
+
+// Scan walks root and returns the regular files selected by opts in lexical order.
+func Scan(root string, opts ScanOptions) ([]string, error) {
+	for _, pattern := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+
+		name := d.Name()
+		if (!opts.Hidden && strings.HasPrefix(name, ".")) || matchesAny(opts.Exclude, name) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			if !opts.Recursive {
+				return filepath.SkipDir
+			}
+			if opts.MaxDepth > 0 && depth(root, path) > opts.MaxDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if len(opts.Include) > 0 && !matchesAny(opts.Include, name) {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	return files, nil
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// depth returns how many directories below root path is.
+func depth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
@@ -0,0 +1,87 @@
+package mvcommon
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{
+		"Show - 01.mkv",
+		"Show - 01.srt",
+		".hidden.mkv",
+		"season/Show - 02.mkv",
+		"season/extras/Show - 03.mkv",
+		".cache/Show - 04.mkv",
+		"samples/Show - 05.mkv",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create temp file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		opts     ScanOptions
+		expected []string
+	}{
+		{
+			name:     "TopLevelOnly",
+			opts:     ScanOptions{},
+			expected: []string{"Show - 01.mkv", "Show - 01.srt"},
+		},
+		{
+			name:     "Recursive",
+			opts:     ScanOptions{Recursive: true},
+			expected: []string{"Show - 01.mkv", "Show - 01.srt", "samples/Show - 05.mkv", "season/Show - 02.mkv", "season/extras/Show - 03.mkv"},
+		},
+		{
+			name:     "MaxDepth",
+			opts:     ScanOptions{Recursive: true, MaxDepth: 1},
+			expected: []string{"Show - 01.mkv", "Show - 01.srt", "samples/Show - 05.mkv", "season/Show - 02.mkv"},
+		},
+		{
+			name:     "IncludeExclude",
+			opts:     ScanOptions{Recursive: true, Include: []string{"*.mkv"}, Exclude: []string{"samples"}},
+			expected: []string{"Show - 01.mkv", "season/Show - 02.mkv", "season/extras/Show - 03.mkv"},
+		},
+		{
+			name:     "Hidden",
+			opts:     ScanOptions{Recursive: true, MaxDepth: 1, Include: []string{"*.mkv"}, Hidden: true},
+			expected: []string{".cache/Show - 04.mkv", ".hidden.mkv", "Show - 01.mkv", "samples/Show - 05.mkv", "season/Show - 02.mkv"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files, err := Scan(root, test.opts)
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			var got []string
+			for _, file := range files {
+				rel, err := filepath.Rel(root, file)
+				if err != nil {
+					t.Fatalf("Scan returned %s outside of root: %v", file, err)
+				}
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("Scan() got %q; want %q", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestScanInvalidPattern(t *testing.T) {
+	if _, err := Scan(t.TempDir(), ScanOptions{Include: []string{"[a-"}}); err == nil {
+		t.Errorf("Scan accepted an invalid pattern")
+	}
+}
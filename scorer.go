@@ -0,0 +1,111 @@
+package mvcommon
+
+import "fmt"
+
+// Candidate is a match a Scorer rates.
+type Candidate struct {
+	// Text is the match as spelled in the first name it was found in.
+	Text string
+	// Length is the length of Text in characters.
+	Length int
+	// Tokens is the number of words in Text, see Words. A word the match cuts short does not count.
+	Tokens int
+	// Position is the average position of the match in the names it was found in, in units.
+	Position float64
+	// Names is the number of names the match was found in.
+	Names int
+}
+
+// Scorer rates the candidate matches of a detection, the highest score wins. A match found in more names always wins
+// before scores are compared, and of the matches found at the same places in the same names only the longest is
+// rated. Ties are settled as they are without a Scorer.
+type Scorer interface {
+	Score(c Candidate) float64
+}
+
+// ScorerFunc adapts a function to a Scorer.
+type ScorerFunc func(c Candidate) float64
+
+// Score returns f(c).
+func (f ScorerFunc) Score(c Candidate) float64 {
+	return f(c)
+}
+
+var (
+	// Earliest prefers the match found earliest in the names, scoring it 1/(1+p) for an average position p.
+	Earliest Scorer = ScorerFunc(func(c Candidate) float64 {
+		return 1 / (1 + c.Position)
+	})
+	// Longest prefers the match with the most characters.
+	Longest Scorer = ScorerFunc(func(c Candidate) float64 {
+		return float64(c.Length)
+	})
+	// MostTokens prefers the match with the most words.
+	MostTokens Scorer = ScorerFunc(func(c Candidate) float64 {
+		return float64(c.Tokens)
+	})
+)
+
+// Weighted is a Scorer adding up the length of a match and its words, each times its weight, less its average
+// position times its weight.
+type Weighted struct {
+	Position float64
+	Length   float64
+	Tokens   float64
+}
+
+// DefaultWeighted is the Weighted Scorer StrategyWeighted selects. It gives a word two characters' worth and takes a
+// character off for every unit the match starts further into the names.
+var DefaultWeighted = Weighted{Position: 1, Length: 1, Tokens: 2}
+
+// Score returns the weighted sum of the length, words and position of c.
+func (w Weighted) Score(c Candidate) float64 {
+	return w.Length*float64(c.Length) + w.Tokens*float64(c.Tokens) - w.Position*c.Position
+}
+
+// Strategy names a built-in Scorer.
+type Strategy string
+
+const (
+	// StrategyAuto uses no Scorer: the earliest match wins, or the longest with a Tokenizer or an Anchor. The default.
+	StrategyAuto Strategy = "auto"
+	// StrategyEarliest selects Earliest.
+	StrategyEarliest Strategy = "earliest"
+	// StrategyLongest selects Longest.
+	StrategyLongest Strategy = "longest"
+	// StrategyTokens selects MostTokens.
+	StrategyTokens Strategy = "tokens"
+	// StrategyWeighted selects DefaultWeighted.
+	StrategyWeighted Strategy = "weighted"
+)
+
+// Strategies lists the valid Strategy values.
+var Strategies = []Strategy{StrategyAuto, StrategyEarliest, StrategyLongest, StrategyTokens, StrategyWeighted}
+
+// ParseStrategy parses a strategy name, an empty string is StrategyAuto.
+func ParseStrategy(s string) (Strategy, error) {
+	if s == "" {
+		return StrategyAuto, nil
+	}
+	for _, strategy := range Strategies {
+		if string(strategy) == s {
+			return strategy, nil
+		}
+	}
+	return "", fmt.Errorf("invalid strategy %q, expected one of %v", s, Strategies)
+}
+
+// Scorer returns the Scorer s selects, nil for StrategyAuto.
+func (s Strategy) Scorer() Scorer {
+	switch s {
+	case StrategyEarliest:
+		return Earliest
+	case StrategyLongest:
+		return Longest
+	case StrategyTokens:
+		return MostTokens
+	case StrategyWeighted:
+		return DefaultWeighted
+	}
+	return nil
+}
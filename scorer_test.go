@@ -0,0 +1,161 @@
+package mvcommon
+
+import "testing"
+
+func TestStrategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		names    []string
+		expected map[Strategy]string
+	}{
+		{
+			name:  "AllAgree",
+			names: []string{"Report 234 - Draft1.txt", "Report 234 - Draft2.txt", "Report 234 - Final.txt"},
+			expected: map[Strategy]string{
+				StrategyAuto:     "Report 234",
+				StrategyEarliest: "Report 234",
+				StrategyLongest:  "Report 234",
+				StrategyTokens:   "Report 234",
+				StrategyWeighted: "Report 234",
+			},
+		},
+		{
+			name:  "StopWordInName",
+			names: []string{"Artist - Album I", "Artist - Album II"},
+			expected: map[Strategy]string{
+				StrategyAuto:     "Artist",
+				StrategyEarliest: "Artist",
+				StrategyLongest:  "Artist",
+				StrategyTokens:   "Artist",
+				StrategyWeighted: "Artist",
+			},
+		},
+		{
+			name:  "UnderscoreNoStopWord",
+			names: []string{"file_one.txt", "file_two.txt", "file_three.txt"},
+			expected: map[Strategy]string{
+				StrategyAuto:     "file",
+				StrategyEarliest: "file",
+				StrategyLongest:  "file",
+				StrategyTokens:   "file",
+				StrategyWeighted: "file",
+			},
+		},
+		{
+			name:  "UnderscoreApple",
+			names: []string{"apple_pie.txt", "apple_crumble.txt", "apple_sauce.txt"},
+			expected: map[Strategy]string{
+				StrategyAuto:     "apple",
+				StrategyEarliest: "apple",
+				StrategyLongest:  "apple",
+				StrategyTokens:   "apple",
+				StrategyWeighted: "apple",
+			},
+		},
+		{
+			name:  "ExactMatch",
+			names: []string{"common_prefix.txt", "common_prefix_log.txt"},
+			expected: map[Strategy]string{
+				StrategyAuto:     "common_prefix",
+				StrategyEarliest: "common_prefix",
+				StrategyLongest:  "common_prefix",
+				StrategyTokens:   "common_prefix",
+				StrategyWeighted: "common_prefix",
+			},
+		},
+		{
+			name:  "StrayLetterInTag",
+			names: []string{"[Draft] Report 234.txt", "[For Review a] Report 234 - Version 2.txt", "[Final] Report 234.txt"},
+			expected: map[Strategy]string{
+				StrategyAuto:     "r",
+				StrategyEarliest: "r",
+				StrategyLongest:  "Report 234",
+				StrategyTokens:   "Report 234",
+				StrategyWeighted: "Report 234",
+			},
+		},
+		{
+			name:  "ShortEarlyMatch",
+			names: []string{"a1 Quarterly Budget Review.pdf", "a2 Quarterly Budget Review.pdf"},
+			expected: map[Strategy]string{
+				StrategyAuto:     "a",
+				StrategyEarliest: "a",
+				StrategyLongest:  "Quarterly Budget Review",
+				StrategyTokens:   "Quarterly Budget Review",
+				StrategyWeighted: "Quarterly Budget Review",
+			},
+		},
+		{
+			name:  "CutWord",
+			names: []string{"IMG_2024 beach holiday.jpg", "IMG_2025 beach holiday.jpg"},
+			expected: map[Strategy]string{
+				StrategyAuto:     "IMG_202",
+				StrategyEarliest: "IMG_202",
+				StrategyLongest:  "beach holiday",
+				StrategyTokens:   "beach holiday",
+				StrategyWeighted: "IMG_202",
+			},
+		},
+		{
+			name:  "Infix",
+			names: []string{"Draft notes 2024 a", "Final notes 2024 b"},
+			expected: map[Strategy]string{
+				StrategyAuto:     "a",
+				StrategyEarliest: "a",
+				StrategyLongest:  "notes 2024",
+				StrategyTokens:   "notes 2024",
+				StrategyWeighted: "notes 2024",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		for _, strategy := range Strategies {
+			t.Run(test.name+"_"+string(strategy), func(t *testing.T) {
+				result, err := NewDetector(WithTrim("_- "), WithScorer(strategy.Scorer())).Detect(test.names)
+				if err != nil {
+					t.Fatalf("Detect(%q) failed: %v", test.names, err)
+				}
+				if result.Prefix != test.expected[strategy] {
+					t.Errorf("Detect(%q) with %s got %q; want %q", test.names, strategy, result.Prefix, test.expected[strategy])
+				}
+			})
+		}
+	}
+}
+
+func TestStrategyScore(t *testing.T) {
+	names := []string{"a1 Quarterly Budget Review.pdf", "a2 Quarterly Budget Review.pdf"}
+	tests := []struct {
+		name     string
+		scorer   Scorer
+		expected float64
+	}{
+		{name: "Earliest", scorer: Earliest, expected: 1},
+		{name: "Longest", scorer: Longest, expected: 23},
+		{name: "MostTokens", scorer: MostTokens, expected: 3},
+		{name: "DefaultWeighted", scorer: DefaultWeighted, expected: 23 + 2*3 - 3},
+		{name: "LengthOnly", scorer: Weighted{Length: 1}, expected: 23},
+	}
+
+	for _, test := range tests {
+		result, err := NewDetector(WithTrim("_- "), WithScorer(test.scorer)).Detect(names)
+		if err != nil {
+			t.Fatalf("Detect(%q) failed: %v", names, err)
+		}
+		if result.Score != test.expected {
+			t.Errorf("Detect(%q) with %s got score %v; want %v", names, test.name, result.Score, test.expected)
+		}
+	}
+}
+
+func TestParseStrategy(t *testing.T) {
+	for _, s := range []string{"", "auto", "earliest", "longest", "tokens", "weighted"} {
+		if _, err := ParseStrategy(s); err != nil {
+			t.Errorf("ParseStrategy(%q) failed: %v", s, err)
+		}
+	}
+	if _, err := ParseStrategy("shortest"); err == nil {
+		t.Errorf("ParseStrategy(\"shortest\") succeeded")
+	}
+}
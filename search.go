@@ -0,0 +1,528 @@
+package mvcommon
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+// occurrence is a match of len units starting at unit pos of a name.
+type occurrence struct {
+	pos int
+	len int
+}
+
+// matchSummary is the match a search settled on. Names lists the names it was found in, Matches holds the match in
+// each of them.
+type matchSummary struct {
+	Names      []int
+	Matches    []occurrence
+	AveragePos int
+	Length     int
+	// Score is the score the Scorer gave the match, if there is one.
+	Score float64
+}
+
+// seed is a unit of name doc a match may start at, found at offset at of the text. len is the most units a match
+// starting there may take in, tail is set if such a match ends where AnchorSuffix requires.
+type seed struct {
+	doc  int
+	pos  int
+	at   int
+	len  int
+	tail bool
+}
+
+// search finds the best match shared by a set of segmented names.
+//
+// Every unit a match may start at is the start of a suffix of one text holding all names. The text is cut into pieces
+// by unique separators wherever a match has to stop: after a unit starting a stop word, and where the extension
+// begins. Sorting the suffixes into a suffix array puts seeds sharing a run of units next to each other, and the runs
+// form a tree of intervals that is walked bottom up. Each interval stands for every match between the depth of its
+// parent and its own, all found at the same places in the same names.
+type search struct {
+	opts         Options
+	segments     []segmented
+	stopWords    []string
+	trimSet      unitSet
+	ends         []int
+	reach        []bool
+	anchor       Anchor
+	preferLonger bool
+	required     int
+
+	seeds []seed
+	// seen and first hold the first occurrence in each name while an interval is scanned, seen is stamped with visit.
+	seen  []int
+	first []int
+	visit int
+	// words holds the bounds of the words of the names a Scorer has been asked about, see Words.
+	words map[int][]int
+}
+
+// candidate is the longest match an interval of seeds offers that satisfies the options.
+type candidate struct {
+	from, to  int
+	depth     int
+	length    int
+	names     int
+	average   int
+	sampleDoc int
+	samplePos int
+	score     float64
+}
+
+// best returns the match the options prefer, nil if there is none.
+func (s *search) best() *matchSummary {
+	var best *candidate
+	s.walk(func(c candidate) {
+		if s.better(c, best) {
+			best = &c
+		}
+	})
+	if best == nil {
+		return nil
+	}
+	return s.summary(*best)
+}
+
+// candidates returns every match satisfying the options, the preferred ones first.
+func (s *search) candidates() []candidate {
+	var candidates []candidate
+	s.walk(func(c candidate) {
+		candidates = append(candidates, c)
+	})
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		switch {
+		case s.better(a, &b):
+			return -1
+		case s.better(b, &a):
+			return 1
+		}
+		return 0
+	})
+	return candidates
+}
+
+// walk calls consider with the longest match satisfying the options of every interval of seeds.
+func (s *search) walk(consider func(c candidate)) {
+	text, alphabet := s.text()
+	if len(s.seeds) == 0 {
+		return
+	}
+	sa := suffixArray(text, alphabet)
+	lcp := lcpArray(text, sa)
+
+	// Keep the suffixes that start at a seed, each with the number of units it shares with the previous one.
+	seedAt := make([]int, len(text))
+	for i := range seedAt {
+		seedAt[i] = -1
+	}
+	for i, sd := range s.seeds {
+		seedAt[sd.at] = i
+	}
+	ordered := make([]seed, 0, len(s.seeds))
+	shared := make([]int, 0, len(s.seeds)+1)
+	run := 0
+	for r, p := range sa {
+		if r > 0 {
+			run = min(run, int(lcp[r]))
+		}
+		i := seedAt[p]
+		if i < 0 {
+			continue
+		}
+		if len(ordered) == 0 {
+			run = 0
+		}
+		ordered = append(ordered, s.seeds[i])
+		shared = append(shared, run)
+		run = len(text)
+	}
+	shared = append(shared, 0)
+	s.seeds = ordered
+	s.seen = make([]int, len(s.segments))
+	s.first = make([]int, len(s.segments))
+
+	offer := func(from, to, depth, parentDepth int) {
+		if c, ok := s.evaluate(from, to, depth, parentDepth); ok {
+			consider(c)
+		}
+	}
+
+	type interval struct {
+		depth int
+		from  int
+	}
+	stack := []interval{{}}
+	for k := 1; k <= len(s.seeds); k++ {
+		h := shared[k]
+		from := k - 1
+		for h < stack[len(stack)-1].depth {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			from = top.from
+			offer(top.from, k, top.depth, max(h, stack[len(stack)-1].depth))
+		}
+		if h > stack[len(stack)-1].depth {
+			stack = append(stack, interval{depth: h, from: from})
+		}
+	}
+	// Matches only a single seed has are only of use when a single name is enough.
+	if s.required <= 1 {
+		for k, sd := range s.seeds {
+			if parentDepth := max(shared[k], shared[k+1]); sd.len > parentDepth {
+				offer(k, k+1, sd.len, parentDepth)
+			}
+		}
+	}
+}
+
+// text returns the text holding every name as unit ids, along with the number of distinct values in it, and collects
+// the seeds. Every separator is a value of its own, below those of the units, so a match sorts before the longer
+// matches it starts.
+func (s *search) text() ([]int32, int) {
+	units := 0
+	for _, end := range s.ends {
+		units += end
+	}
+	ids := make(map[string]int32)
+	text := make([]int32, 0, 2*units)
+	s.seeds = make([]seed, 0, units)
+	var at []int
+	separators := int32(0)
+	separate := func() {
+		separators++
+		text = append(text, -separators)
+	}
+
+	for ni, seg := range s.segments {
+		limit := s.ends[ni]
+		if !s.reach[ni] {
+			// Only a match of a single unit may take in the last unit, so it gets a piece of its own.
+			limit--
+		}
+		at = slices.Grow(at[:0], s.ends[ni])[:s.ends[ni]]
+		for u := range at {
+			if u == limit && u > 0 && text[len(text)-1] >= 0 {
+				separate()
+			}
+			unit := seg.unit(u)
+			id, ok := ids[unit]
+			if !ok {
+				id = int32(len(ids))
+				ids[unit] = id
+			}
+			at[u] = len(text)
+			text = append(text, id)
+			if u == limit || s.stopWordAt(seg, u) > 0 {
+				separate()
+			}
+		}
+		if len(at) > 0 && text[len(text)-1] >= 0 {
+			separate()
+		}
+
+		// Seeds are the units that are neither trim characters nor covered by a stop word.
+		for u := 0; u < s.ends[ni]; u++ {
+			if s.trimSet.has(seg.unit(u)) {
+				continue
+			}
+			if skip := s.stopWordAt(seg, u); skip > 0 {
+				for end := seg.keyBounds[u] + skip; seg.keyBounds[u+1] < end; {
+					u++
+				}
+				continue
+			}
+			n := 0
+			for text[at[u]+n] >= 0 {
+				n++
+			}
+			s.seeds = append(s.seeds, seed{doc: ni, pos: u, at: at[u], len: n, tail: u+n == s.ends[ni]})
+			if s.anchor == AnchorPrefix {
+				break
+			}
+		}
+	}
+
+	for i, v := range text {
+		if v < 0 {
+			text[i] = -v - 1
+		} else {
+			text[i] = v + separators
+		}
+	}
+	return text, int(separators) + len(ids)
+}
+
+// stopWordAt returns the length in bytes of the longest stop word starting at unit u, 0 if there is none. A stop word
+// running to the end of the name does not count.
+func (s *search) stopWordAt(seg segmented, u int) int {
+	name, b := seg.key, seg.keyBounds
+	skip := 0
+	for _, stopWord := range s.stopWords {
+		if len(name) > b[u]+len(stopWord) && strings.HasPrefix(name[b[u]:], stopWord) {
+			skip = max(len(stopWord), skip)
+		}
+	}
+	return skip
+}
+
+// scan stamps the first occurrence in every name the seeds [from, to) are found in and returns the number of names,
+// the sum of the positions, and the first name with its first occurrence as a sample. With AnchorSuffix only the seeds
+// ending a name at depth count as found.
+func (s *search) scan(from, to, depth int) (names, sum, sampleDoc, samplePos int) {
+	s.visit++
+	sampleDoc = -1
+	for _, sd := range s.seeds[from:to] {
+		if sampleDoc < 0 || sd.doc < sampleDoc || (sd.doc == sampleDoc && sd.pos < samplePos) {
+			sampleDoc, samplePos = sd.doc, sd.pos
+		}
+		if s.anchor == AnchorSuffix && (sd.len != depth || !sd.tail) {
+			continue
+		}
+		switch {
+		case s.seen[sd.doc] != s.visit:
+			s.seen[sd.doc] = s.visit
+			s.first[sd.doc] = sd.pos
+			sum += sd.pos
+			names++
+		case sd.pos < s.first[sd.doc]:
+			sum -= s.first[sd.doc] - sd.pos
+			s.first[sd.doc] = sd.pos
+		}
+	}
+	return names, sum, sampleDoc, samplePos
+}
+
+// evaluate returns the longest match between parentDepth and depth units the seeds [from, to) share, if any satisfies
+// the options. All of them are found at the same places, so the longest is always preferred.
+func (s *search) evaluate(from, to, depth, parentDepth int) (candidate, bool) {
+	if to-from < s.required {
+		return candidate{}, false
+	}
+	names, sum, sampleDoc, samplePos := s.scan(from, to, depth)
+	if names < s.required {
+		return candidate{}, false
+	}
+
+	seg := s.segments[sampleDoc]
+	length := depth
+	for length > parentDepth && s.trimSet.has(seg.unit(samplePos+length-1)) {
+		if s.anchor == AnchorSuffix {
+			return candidate{}, false
+		}
+		length--
+	}
+	if length == parentDepth {
+		return candidate{}, false
+	}
+	chars := length
+	if s.opts.Tokenizer != nil {
+		chars = uniseg.GraphemeClusterCount(seg.name[seg.bounds[samplePos]:seg.bounds[samplePos+length]])
+	}
+	if chars < s.opts.MinLength {
+		return candidate{}, false
+	}
+	c := candidate{
+		from:      from,
+		to:        to,
+		depth:     depth,
+		length:    length,
+		names:     names,
+		average:   sum * 100 / names,
+		sampleDoc: sampleDoc,
+		samplePos: samplePos,
+	}
+	if s.opts.Scorer != nil {
+		text := seg.name[seg.bounds[samplePos]:seg.bounds[samplePos+length]]
+		c.score = s.opts.Scorer.Score(Candidate{
+			Text:     text,
+			Length:   uniseg.GraphemeClusterCount(text),
+			Tokens:   s.wholeWords(sampleDoc, seg.bounds[samplePos], seg.bounds[samplePos+length]),
+			Position: float64(c.average) / 100,
+			Names:    names,
+		})
+	}
+	return c, true
+}
+
+// wholeWords returns the number of words of name doc lying whole within bytes [from, to) of it.
+func (s *search) wholeWords(doc, from, to int) int {
+	name := s.segments[doc].name
+	bounds, ok := s.words[doc]
+	if !ok {
+		if s.words == nil {
+			s.words = make(map[int][]int)
+		}
+		bounds = Words(name)
+		s.words[doc] = bounds
+	}
+	words := 0
+	for i := 0; i+1 < len(bounds); i++ {
+		if bounds[i] < from || bounds[i+1] > to {
+			continue
+		}
+		if r, _ := utf8.DecodeRuneInString(name[bounds[i]:]); classify(r) != classSeparator {
+			words++
+		}
+	}
+	return words
+}
+
+// better reports whether c is preferred over best. Matches found in more names win, so names are only left out when
+// they have to be, then the one the Scorer rates higher. Then the earliest match wins unless longer matches are
+// preferred, in which case it only settles ties between matches of the same length, and ties go to the match whose
+// key sorts last.
+func (s *search) better(c candidate, best *candidate) bool {
+	if best == nil {
+		return true
+	}
+	if c.names != best.names {
+		return c.names > best.names
+	}
+	if s.opts.Scorer != nil && c.score != best.score {
+		return c.score > best.score
+	}
+	if s.preferLonger && c.length != best.length {
+		return c.length > best.length
+	}
+	if c.average != best.average {
+		return c.average < best.average
+	}
+	if c.length != best.length {
+		return c.length > best.length
+	}
+	return s.key(c) > s.key(*best)
+}
+
+// key returns the key of the match c stands for.
+func (s *search) key(c candidate) string {
+	return s.segments[c.sampleDoc].keyOf(c.samplePos, c.samplePos+c.length)
+}
+
+// summary returns the occurrences of c in the names it was found in.
+func (s *search) summary(c candidate) *matchSummary {
+	names, _, _, _ := s.scan(c.from, c.to, c.depth)
+	summary := &matchSummary{
+		Names:      make([]int, 0, names),
+		Matches:    make([]occurrence, 0, names),
+		AveragePos: c.average,
+		Length:     c.length,
+		Score:      c.score,
+	}
+	for _, sd := range s.seeds[c.from:c.to] {
+		if s.seen[sd.doc] == s.visit {
+			summary.Names = append(summary.Names, sd.doc)
+			s.seen[sd.doc] = 0
+		}
+	}
+	slices.Sort(summary.Names)
+	for _, ni := range summary.Names {
+		summary.Matches = append(summary.Matches, occurrence{pos: s.first[ni], len: c.length})
+	}
+	return summary
+}
+
+// suffixArray returns the start offsets of the suffixes of text in sorted order, using prefix doubling with counting
+// sorts. Every value of text must be in [0, alphabet).
+func suffixArray(text []int32, alphabet int) []int32 {
+	n := len(text)
+	sa := make([]int32, n)
+	rank := make([]int32, n)
+	next := make([]int32, n)
+	count := make([]int32, max(alphabet, n)+1)
+
+	for _, c := range text {
+		count[c+1]++
+	}
+	for i := 1; i < len(count); i++ {
+		count[i] += count[i-1]
+	}
+	for i, c := range text {
+		sa[count[c]] = int32(i)
+		count[c]++
+	}
+	classes := 0
+	for i, p := range sa {
+		if i > 0 && text[p] != text[sa[i-1]] {
+			classes++
+		}
+		rank[p] = int32(classes)
+	}
+	classes++
+
+	for k := 1; classes < n; k <<= 1 {
+		// Order by the rank k further on first, the suffixes shorter than that going first, then stably by rank.
+		j := 0
+		for i := max(n-k, 0); i < n; i++ {
+			next[j] = int32(i)
+			j++
+		}
+		for _, p := range sa {
+			if int(p) >= k {
+				next[j] = p - int32(k)
+				j++
+			}
+		}
+		clear(count[:classes+1])
+		for _, p := range next {
+			count[rank[p]+1]++
+		}
+		for i := 1; i <= classes; i++ {
+			count[i] += count[i-1]
+		}
+		for _, p := range next {
+			sa[count[rank[p]]] = p
+			count[rank[p]]++
+		}
+
+		second := func(p int32) int32 {
+			if int(p)+k < n {
+				return rank[int(p)+k]
+			}
+			return -1
+		}
+		classes = 1
+		next[sa[0]] = 0
+		for i := 1; i < n; i++ {
+			p, prev := sa[i], sa[i-1]
+			if rank[p] != rank[prev] || second(p) != second(prev) {
+				classes++
+			}
+			next[p] = int32(classes - 1)
+		}
+		rank, next = next, rank
+	}
+	return sa
+}
+
+// lcpArray returns the length of the common prefix of every suffix in sa and the one before it, using Kasai's
+// algorithm.
+func lcpArray(text, sa []int32) []int32 {
+	n := len(text)
+	rank := make([]int32, n)
+	for i, p := range sa {
+		rank[p] = int32(i)
+	}
+	lcp := make([]int32, n)
+	h := 0
+	for i := range n {
+		r := rank[i]
+		if r == 0 {
+			h = 0
+			continue
+		}
+		j := int(sa[r-1])
+		for i+h < n && j+h < n && text[i+h] == text[j+h] {
+			h++
+		}
+		lcp[r] = int32(h)
+		if h > 0 {
+			h--
+		}
+	}
+	return lcp
+}
@@ -0,0 +1,76 @@
+package mvcommon
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer splits a name into the units detection matches whole. It returns the byte offsets at which the units
+// start, followed by len(name). Units must not split a grapheme cluster.
+type Tokenizer func(name string) []int
+
+// Words is a Tokenizer splitting names into words and separators, so a prefix never ends mid-word. A word is a run of
+// letters or of digits, and a new word starts at a camelCase boundary as in "reportDraft" or "HTTPServer". Chinese and
+// Japanese characters, written without spaces, are a word each. Every other character, such as a space, "_", "-", "."
+// or a bracket, is a separator unit of its own.
+func Words(name string) []int {
+	clusters := graphemeBounds(name)
+	classes := make([]tokenClass, len(clusters)-1)
+	for i := range classes {
+		r, _ := utf8.DecodeRuneInString(name[clusters[i]:])
+		classes[i] = classify(r)
+	}
+
+	bounds := []int{0}
+	for i := 1; i < len(classes); i++ {
+		if wordBoundary(classes, i) {
+			bounds = append(bounds, clusters[i])
+		}
+	}
+	if len(name) > 0 {
+		bounds = append(bounds, len(name))
+	}
+	return bounds
+}
+
+type tokenClass int
+
+const (
+	classSeparator tokenClass = iota
+	classIdeograph
+	classDigit
+	classUpper
+	classLower
+)
+
+func classify(r rune) tokenClass {
+	switch {
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+		return classIdeograph
+	case unicode.IsDigit(r):
+		return classDigit
+	case unicode.IsUpper(r), unicode.IsTitle(r):
+		return classUpper
+	case unicode.IsLetter(r), unicode.IsMark(r):
+		return classLower
+	default:
+		return classSeparator
+	}
+}
+
+// wordBoundary reports whether a new unit starts at cluster i.
+func wordBoundary(classes []tokenClass, i int) bool {
+	prev, cur := classes[i-1], classes[i]
+	isLetter := func(c tokenClass) bool { return c == classUpper || c == classLower }
+	switch {
+	case prev == classSeparator, cur == classSeparator, prev == classIdeograph, cur == classIdeograph:
+		return true
+	case isLetter(prev) != isLetter(cur):
+		return true
+	case prev == classLower && cur == classUpper:
+		return true
+	case prev == classUpper && cur == classUpper:
+		return i+1 < len(classes) && classes[i+1] == classLower
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package mvcommon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{name: "Empty", input: "", expected: nil},
+		{name: "Separators", input: "Report 234 - Draft_1.txt", expected: []string{"Report", " ", "234", " ", "-", " ", "Draft", "_", "1", ".", "txt"}},
+		{name: "Brackets", input: "[Final] Notes", expected: []string{"[", "Final", "]", " ", "Notes"}},
+		{name: "CamelCase", input: "projectAlphaDraft", expected: []string{"project", "Alpha", "Draft"}},
+		{name: "Acronym", input: "HTTPServerLog2", expected: []string{"HTTP", "Server", "Log", "2"}},
+		{name: "Accented", input: "Résumé Ölçek", expected: []string{"Résumé", " ", "Ölçek"}},
+		{name: "Ideographs", input: "報告書 第1版", expected: []string{"報", "告", "書", " ", "第", "1", "版"}},
+		{name: "Emoji", input: "🎵Song", expected: []string{"🎵", "Song"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bounds := Words(test.input)
+			var tokens []string
+			for i := 0; i < len(bounds)-1; i++ {
+				tokens = append(tokens, test.input[bounds[i]:bounds[i+1]])
+			}
+			if !reflect.DeepEqual(tokens, test.expected) {
+				t.Errorf("Words(%q) got %q; want %q", test.input, tokens, test.expected)
+			}
+		})
+	}
+}
+
+func TestDetectorDetectTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		names    []string
+		opts     []Option
+		expected string
+	}{
+		{
+			name:     "Characters_EndsMidWord",
+			names:    []string{"projectAlphaReview.txt", "projectAlphaRelease.txt"},
+			expected: "projectAlphaRe",
+		},
+		{
+			name:     "Tokens_WholeWords",
+			names:    []string{"projectAlphaReview.txt", "projectAlphaRelease.txt"},
+			opts:     []Option{WithTokenizer(Words)},
+			expected: "projectAlpha",
+		},
+		{
+			name:     "Characters_EarliestFragment",
+			names:    []string{"[Draft] Report 234.txt", "[For a Review] Report 234 - Version 2.txt", "[Final] Report 234.txt"},
+			opts:     []Option{WithMinLength(0)},
+			expected: "a",
+		},
+		{
+			name:     "Tokens_LongestRun",
+			names:    []string{"[Draft] Report 234.txt", "[For a Review] Report 234 - Version 2.txt", "[Final] Report 234.txt"},
+			opts:     []Option{WithMinLength(0), WithTokenizer(Words)},
+			expected: "Report 234",
+		},
+		{
+			name:     "Tokens_IgnoreCase",
+			names:    []string{"ACME invoice 1.pdf", "Acme invoice 2.pdf", "acme invoice 3.pdf", "Acme invoice 4.pdf"},
+			opts:     []Option{WithTokenizer(Words), WithFoldCase(true)},
+			expected: "Acme invoice",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := append([]Option{WithTrim("_- ."), WithMinLength(3)}, test.opts...)
+			result, err := NewDetector(opts...).Detect(test.names)
+			if err != nil {
+				t.Fatalf("Detect(%q) failed: %v", test.names, err)
+			}
+			if result.Prefix != test.expected {
+				t.Errorf("Detect(%q) got %q; want %q", test.names, result.Prefix, test.expected)
+			}
+		})
+	}
+}
@@ -2,35 +2,112 @@ package mvcommon
 
 import (
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
 
+// ParseNumberRanges parses a comma separated selection of the numbers 1 to max, returning the selected indices in
+// order without duplicates. A term is one of:
+//
+//   - a number, 3, or a range, 1-3, open at either end, 5- or -3
+//   - all, for every number
+//   - ~, to invert what the terms before it selected
+//   - any of these after ! or ^ to leave them out again, !3 or ^4-6
+//
+// Terms apply from left to right. A selection starting with a term leaving numbers out starts from all of them, so
+// !3 is every number but 3.
 func ParseNumberRanges(input string, max int) ([]int, error) {
-	var indices []int
-	ranges := strings.Split(input, ",")
-	for _, r := range ranges {
-		r = strings.TrimSpace(r)
-		if strings.Contains(r, "-") {
-			parts := strings.Split(r, "-")
-			if len(parts) != 2 {
-				return nil, fmt.Errorf("invalid range: %s", r)
-			}
-			start, err1 := strconv.Atoi(parts[0])
-			end, err2 := strconv.Atoi(parts[1])
-			if err1 != nil || err2 != nil || start < 1 || end > max || start > end {
-				return nil, fmt.Errorf("invalid range: %s", r)
+	return parseSelection(input, max, nil)
+}
+
+// ParseSelection parses a selection of names like ParseNumberRanges, where a term may also pick names by their base
+// name: /draft/ selects the names containing draft in any case, and a glob such as *.jpg the names matching it.
+func ParseSelection(input string, names []string) ([]int, error) {
+	return parseSelection(input, len(names), names)
+}
+
+func parseSelection(input string, max int, names []string) ([]int, error) {
+	selected := make([]bool, max)
+	for i, term := range strings.Split(input, ",") {
+		term = strings.TrimSpace(term)
+		include := true
+		if rest, ok := strings.CutPrefix(term, "!"); ok {
+			term, include = rest, false
+		} else if rest, ok := strings.CutPrefix(term, "^"); ok {
+			term, include = rest, false
+		}
+		if i == 0 && !include {
+			for j := range selected {
+				selected[j] = true
 			}
-			for i := start; i <= end; i++ {
-				indices = append(indices, i-1)
+		}
+
+		if term == "~" {
+			for j := range selected {
+				selected[j] = !selected[j]
 			}
-		} else {
-			num, err := strconv.Atoi(r)
-			if err != nil || num < 1 || num > max {
-				return nil, fmt.Errorf("invalid number: %s", r)
+			continue
+		}
+		match, err := parseTerm(term, max, names)
+		if err != nil {
+			return nil, err
+		}
+		for j := range selected {
+			if match(j) {
+				selected[j] = include
 			}
-			indices = append(indices, num-1)
+		}
+	}
+
+	indices := []int{}
+	for i, ok := range selected {
+		if ok {
+			indices = append(indices, i)
 		}
 	}
 	return indices, nil
 }
+
+// parseTerm parses a term of a selection that is not negated or inverted, returning whether it selects index i.
+func parseTerm(term string, max int, names []string) (func(i int) bool, error) {
+	switch {
+	case term == "all":
+		return func(int) bool { return true }, nil
+	case names != nil && len(term) > 2 && strings.HasPrefix(term, "/") && strings.HasSuffix(term, "/"):
+		substr := strings.ToLower(term[1 : len(term)-1])
+		return func(i int) bool {
+			return strings.Contains(strings.ToLower(filepath.Base(names[i])), substr)
+		}, nil
+	case names != nil && strings.ContainsAny(term, "*?["):
+		if _, err := filepath.Match(term, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern: %s", term)
+		}
+		return func(i int) bool {
+			ok, _ := filepath.Match(term, filepath.Base(names[i]))
+			return ok
+		}, nil
+	case strings.Contains(term, "-"):
+		parts := strings.Split(term, "-")
+		if len(parts) != 2 || parts[0] == "" && parts[1] == "" {
+			return nil, fmt.Errorf("invalid range: %s", term)
+		}
+		start, end := 1, max
+		var err1, err2 error
+		if parts[0] != "" {
+			start, err1 = strconv.Atoi(parts[0])
+		}
+		if parts[1] != "" {
+			end, err2 = strconv.Atoi(parts[1])
+		}
+		if err1 != nil || err2 != nil || start < 1 || end > max || start > end {
+			return nil, fmt.Errorf("invalid range: %s", term)
+		}
+		return func(i int) bool { return i >= start-1 && i < end }, nil
+	}
+	num, err := strconv.Atoi(term)
+	if err != nil || num < 1 || num > max {
+		return nil, fmt.Errorf("invalid number: %s", term)
+	}
+	return func(i int) bool { return i == num-1 }, nil
+}
@@ -90,7 +90,91 @@ func TestParseNumberRanges(t *testing.T) {
 			name:  "Valid input with overlapping ranges",
 			input: "1-3,2-4",
 			max:   5,
-			want:  []int{0, 1, 2, 1, 2, 3}, // Overlaps preserved
+			want:  []int{0, 1, 2, 3},
+		},
+		{
+			name:  "Valid out of order input",
+			input: "5,1,3,1",
+			max:   5,
+			want:  []int{0, 2, 4},
+		},
+		{
+			name:  "Valid open ended range",
+			input: "3-",
+			max:   5,
+			want:  []int{2, 3, 4},
+		},
+		{
+			name:  "Valid open started range",
+			input: "-2",
+			max:   5,
+			want:  []int{0, 1},
+		},
+		{
+			name:  "Valid all",
+			input: "all",
+			max:   3,
+			want:  []int{0, 1, 2},
+		},
+		{
+			name:  "Valid exclusion after selection",
+			input: "1-5,!3",
+			max:   5,
+			want:  []int{0, 1, 3, 4},
+		},
+		{
+			name:  "Valid exclusion alone",
+			input: "!3",
+			max:   5,
+			want:  []int{0, 1, 3, 4},
+		},
+		{
+			name:  "Valid excluded range",
+			input: "^2-4",
+			max:   5,
+			want:  []int{0, 4},
+		},
+		{
+			name:  "Valid excluded open range",
+			input: "all,!4-",
+			max:   5,
+			want:  []int{0, 1, 2},
+		},
+		{
+			name:  "Valid inversion",
+			input: "1-2,~",
+			max:   5,
+			want:  []int{2, 3, 4},
+		},
+		{
+			name:  "Valid inversion then selection",
+			input: "1,~,1",
+			max:   3,
+			want:  []int{0, 1, 2},
+		},
+		{
+			name:  "Valid exclusion of everything",
+			input: "!all",
+			max:   3,
+			want:  []int{},
+		},
+		{
+			name:    "Invalid range without bounds",
+			input:   "-",
+			max:     5,
+			wantErr: errors.New("invalid range: -"),
+		},
+		{
+			name:    "Invalid exclusion",
+			input:   "!6",
+			max:     5,
+			wantErr: errors.New("invalid number: 6"),
+		},
+		{
+			name:    "Invalid selector without names",
+			input:   "/draft/",
+			max:     5,
+			wantErr: errors.New("invalid number: /draft/"),
 		},
 	}
 
@@ -118,3 +202,49 @@ func TestParseNumberRanges(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSelection(t *testing.T) {
+	names := []string{"Report - Draft1.txt", "Report - Final.txt", "photo.jpg", "Report - draft2.txt", "scan.JPG"}
+	paths := []string{"drafts/Report - Final.txt", "drafts/Report - Draft1.txt", "photos/scan.jpg", "/home/draft/notes.txt"}
+	tests := []struct {
+		name    string
+		input   string
+		names   []string
+		want    []int
+		wantErr bool
+	}{
+		{name: "Numbers", input: "2,1", want: []int{0, 1}},
+		{name: "Substring", input: "/draft/", want: []int{0, 3}},
+		{name: "Glob", input: "*.jpg", want: []int{2}},
+		{name: "GlobAndNumber", input: "*.jpg,5", want: []int{2, 4}},
+		{name: "ExcludedSubstring", input: "!/draft/", want: []int{1, 2, 4}},
+		{name: "ExcludedGlob", input: "1-4,^*.txt", want: []int{2}},
+		{name: "InvertedSubstring", input: "/report/,~", want: []int{2, 4}},
+		{name: "NoMatch", input: "/invoice/", want: []int{}},
+		{name: "SlashAlone", input: "/", wantErr: true},
+		{name: "InvalidGlob", input: "[a", wantErr: true},
+		{name: "SubstringInDirectory", input: "/draft/", names: paths, want: []int{1}},
+		{name: "GlobInDirectory", input: "*.jpg", names: paths, want: []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.names == nil {
+				tt.names = names
+			}
+			got, err := ParseSelection(tt.input, tt.names)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseSelection(%q) = %v, want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSelection(%q) unexpected error: %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSelection(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
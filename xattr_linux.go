@@ -0,0 +1,45 @@
+//go:build linux
+
+package mvcommon
+
+import (
+	"bytes"
+	"errors"
+	"syscall"
+)
+
+// copyXattrs copies the extended attributes of source onto destination.
+func copyXattrs(source, destination string) error {
+	size, err := syscall.Listxattr(source, nil)
+	if errors.Is(err, syscall.ENOTSUP) || size == 0 {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	list := make([]byte, size)
+	size, err = syscall.Listxattr(source, list)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range bytes.Split(list[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		attr := string(name)
+		valueSize, err := syscall.Getxattr(source, attr, nil)
+		if err != nil {
+			return err
+		}
+		value := make([]byte, valueSize)
+		valueSize, err = syscall.Getxattr(source, attr, value)
+		if err != nil {
+			return err
+		}
+		if err := syscall.Setxattr(destination, attr, value[:valueSize], 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package mvcommon
+
+// copyXattrs is a no-op on platforms without extended attribute support.
+func copyXattrs(source, destination string) error {
+	return nil
+}